@@ -0,0 +1,275 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package porcupine
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Converts float samples within [-1, 1] to 16-bit PCM, clamping out of range values. NaN samples are
+// converted to silence and Inf samples to full scale. Returns the number of NaN or Inf samples encountered.
+func float32ToInt16(pcm []float32) ([]int16, int) {
+	pcmInt16 := make([]int16, len(pcm))
+	nonFinite := float32ToInt16Into(pcmInt16, pcm)
+	return pcmInt16, nonFinite
+}
+
+// Same as float32ToInt16, but writes the converted samples to `dst`, which must be at least as long as `pcm`.
+func float32ToInt16Into(dst []int16, pcm []float32) int {
+	nonFinite := 0
+	for i, sample := range pcm {
+		value := float64(sample)
+		if math.IsNaN(value) {
+			nonFinite++
+			value = 0
+		} else if math.IsInf(value, 0) {
+			nonFinite++
+		}
+
+		scaled := value * math.MaxInt16
+		if scaled > math.MaxInt16 {
+			scaled = math.MaxInt16
+		} else if scaled < math.MinInt16 {
+			scaled = math.MinInt16
+		}
+		dst[i] = int16(math.Round(scaled))
+	}
+	return nonFinite
+}
+
+// Converter struct
+type Converter struct {
+	srcRate     int
+	srcChannels int
+
+	// samples of an incomplete multi-channel frame carried over from the previous call
+	leftover []int16
+
+	resampler *resampler
+}
+
+// Creates a Converter that turns interleaved PCM captured at `srcRate` with `srcChannels` channels into mono
+// audio at `SampleRate()`, ready to be split into frames of `FrameLength()` samples.
+func NewConverter(srcRate, srcChannels int) (*Converter, error) {
+	if srcRate <= 0 {
+		return nil, newPorcupineError(INVALID_ARGUMENT, "Sample rate of %d is invalid. Must be greater than 0.",
+			srcRate)
+	}
+	if srcChannels <= 0 {
+		return nil, newPorcupineError(INVALID_ARGUMENT, "Channel count of %d is invalid. Must be greater than 0.",
+			srcChannels)
+	}
+
+	dstRate, err := librarySampleRate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Converter{
+		srcRate:     srcRate,
+		srcChannels: srcChannels,
+		resampler:   newResampler(srcRate, dstRate),
+	}, nil
+}
+
+// Downmixes and resamples a chunk of interleaved PCM. Chunks may be of any length; partial multi-channel frames
+// and resampling state are carried over to the next call so consecutive chunks convert without artifacts.
+func (converter *Converter) Process(pcm []int16) []int16 {
+	if len(converter.leftover) > 0 {
+		pcm = append(converter.leftover, pcm...)
+		converter.leftover = nil
+	}
+
+	channels := converter.srcChannels
+	usable := len(pcm) - len(pcm)%channels
+	if usable < len(pcm) {
+		converter.leftover = append([]int16(nil), pcm[usable:]...)
+	}
+
+	return converter.resampler.process(DownmixToMono(pcm[:usable], channels))
+}
+
+// Averages the channels of interleaved PCM into mono PCM at the same sample rate. A trailing partial frame, i.e.
+// fewer than `channels` samples left at the end of `interleaved`, is dropped; use a Converter to carry it over to
+// the next chunk of a stream. Returns a copy of the input if `channels` is 1 and nil if it is less than 1.
+func DownmixToMono(interleaved []int16, channels int) []int16 {
+	if channels < 1 {
+		return nil
+	}
+	if channels == 1 {
+		return append([]int16(nil), interleaved...)
+	}
+
+	mono := make([]int16, len(interleaved)/channels)
+	for i := range mono {
+		sum := 0
+		for c := 0; c < channels; c++ {
+			sum += int(interleaved[i*channels+c])
+		}
+		mono[i] = int16(sum / channels)
+	}
+	return mono
+}
+
+// Resampler struct
+type Resampler struct {
+	resampler *resampler
+}
+
+// Creates a Resampler that converts mono PCM captured at `inputRate` to `SampleRate()` using linear
+// interpolation. Use a Converter for multi-channel input.
+func NewResampler(inputRate int) (*Resampler, error) {
+	if inputRate <= 0 {
+		return nil, newPorcupineError(INVALID_ARGUMENT, "Sample rate of %d is invalid. Must be greater than 0.",
+			inputRate)
+	}
+	dstRate, err := librarySampleRate()
+	if err != nil {
+		return nil, err
+	}
+	return &Resampler{resampler: newResampler(inputRate, dstRate)}, nil
+}
+
+// Returns the sample rate of the default native library, or the reason it could not be loaded. Resamplers divide
+// by the rate, so they can't be created without it.
+func librarySampleRate() (int, error) {
+	sampleRate := SampleRate()
+	if sampleRate > 0 {
+		return sampleRate, nil
+	}
+	if err := LibraryError(); err != nil {
+		return 0, fmt.Errorf("sample rate of the native library is unavailable: %w", err)
+	}
+	return 0, newPorcupineError(RUNTIME_ERROR, "Native library reported a sample rate of %d.", sampleRate)
+}
+
+// Resamples a chunk of a continuous stream. Chunks may be of any length; the interpolation state is carried over
+// to the next call, so consecutive chunks resample without artifacts at their boundaries.
+func (resampler *Resampler) Process(input []int16) []int16 {
+	return resampler.resampler.process(input)
+}
+
+// Resamples a complete recording of mono PCM at `inputRate` to `SampleRate()`. Use a Resampler for streams that
+// arrive in chunks, since resampling each chunk separately with Resample introduces artifacts at the boundaries.
+func Resample(input []int16, inputRate int) ([]int16, error) {
+	resampler, err := NewResampler(inputRate)
+	if err != nil {
+		return nil, err
+	}
+	return resampler.Process(input), nil
+}
+
+// Stateful linear interpolation resampler. Positions are tracked as exact fractions of the output rate so
+// long streams don't drift.
+type resampler struct {
+	srcRate int
+	dstRate int
+
+	// position of the next output sample relative to the start of the next input chunk, in units of
+	// 1/dstRate input samples. Negative positions fall between `last` and the first sample of the chunk.
+	pos int
+
+	last    int16
+	hasLast bool
+}
+
+func newResampler(srcRate, dstRate int) *resampler {
+	return &resampler{srcRate: srcRate, dstRate: dstRate}
+}
+
+func (r *resampler) process(input []int16) []int16 {
+	if len(input) == 0 {
+		return nil
+	}
+	if r.srcRate == r.dstRate {
+		return append([]int16(nil), input...)
+	}
+
+	sample := func(i int) int {
+		if i < 0 {
+			if r.hasLast {
+				return int(r.last)
+			}
+			return int(input[0])
+		}
+		return int(input[i])
+	}
+
+	output := make([]int16, 0, len(input)*r.dstRate/r.srcRate+1)
+	limit := (len(input) - 1) * r.dstRate
+	for ; r.pos <= limit; r.pos += r.srcRate {
+		index := r.pos / r.dstRate
+		frac := r.pos % r.dstRate
+		if r.pos < 0 {
+			index = -1
+			frac = r.pos + r.dstRate
+		}
+
+		a := sample(index)
+		if frac == 0 {
+			output = append(output, int16(a))
+			continue
+		}
+		b := sample(index + 1)
+		output = append(output, int16(a+(b-a)*frac/r.dstRate))
+	}
+
+	r.pos -= len(input) * r.dstRate
+	r.last = input[len(input)-1]
+	r.hasLast = true
+	return output
+}
+
+// Decodes 16-bit PCM bytes in the given byte order into `dst`, which must hold `len(src) / 2` samples.
+func decodePCM(dst []int16, src []byte, order binary.ByteOrder) {
+	for i := range dst {
+		dst[i] = int16(order.Uint16(src[i*2 : i*2+2]))
+	}
+}
+
+// Converts little-endian 16-bit PCM bytes, e.g. the data of a WAV file or a raw capture stream, to samples. The
+// bytes are decoded explicitly, so the result doesn't depend on the byte order of the host, unlike reinterpreting
+// the bytes with `unsafe`. Returns an error if `b` holds an odd number of bytes.
+func BytesToInt16LE(b []byte) ([]int16, error) {
+	if len(b)%2 != 0 {
+		return nil, newPorcupineError(INVALID_ARGUMENT, "Input data size (%d bytes) is not a whole number of "+
+			"16-bit samples", len(b))
+	}
+	pcm := make([]int16, len(b)/2)
+	decodePCM(pcm, b, binary.LittleEndian)
+	return pcm, nil
+}
+
+// Converts samples to little-endian 16-bit PCM bytes, regardless of the byte order of the host. The inverse of
+// `BytesToInt16LE`.
+func Int16ToBytesLE(s []int16) []byte {
+	b := make([]byte, len(s)*2)
+	for i, sample := range s {
+		binary.LittleEndian.PutUint16(b[i*2:], uint16(sample))
+	}
+	return b
+}
+
+// Returns the RMS level of 16-bit PCM, from 0 (silence) to 1 (full scale).
+func rmsLevel(pcm []int16) float64 {
+	if len(pcm) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, sample := range pcm {
+		value := float64(sample) / -math.MinInt16
+		sum += value * value
+	}
+	return math.Sqrt(sum / float64(len(pcm)))
+}
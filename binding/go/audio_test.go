@@ -0,0 +1,217 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porcupine
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func sineWave(sampleRate int, numSamples int, channels int) []int16 {
+	pcm := make([]int16, numSamples*channels)
+	for i := 0; i < numSamples; i++ {
+		value := int16(10000 * math.Sin(2*math.Pi*440*float64(i)/float64(sampleRate)))
+		for c := 0; c < channels; c++ {
+			pcm[i*channels+c] = value
+		}
+	}
+	return pcm
+}
+
+func checkSineWave(t *testing.T, pcm []int16, sampleRate int) {
+	expected := sineWave(sampleRate, len(pcm), 1)
+	for i := range pcm {
+		if math.Abs(float64(pcm[i])-float64(expected[i])) > 100 {
+			t.Fatalf("Sample %d is %d, expected approximately %d", i, pcm[i], expected[i])
+		}
+	}
+}
+
+func TestConverterStereo48k(t *testing.T) {
+	converter, err := NewConverter(48000, 2)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	input := sineWave(48000, 48000, 2)
+
+	// feed uneven chunks, including ones that split a stereo frame
+	var output []int16
+	for start := 0; start < len(input); start += 1001 {
+		end := start + 1001
+		if end > len(input) {
+			end = len(input)
+		}
+		output = append(output, converter.Process(input[start:end])...)
+	}
+
+	if len(output) != SampleRate() {
+		t.Fatalf("Expected %d output samples, but got %d", SampleRate(), len(output))
+	}
+	checkSineWave(t, output, SampleRate())
+}
+
+func TestConverterMono44k(t *testing.T) {
+	converter, err := NewConverter(44100, 1)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	input := sineWave(44100, 44100, 1)
+	output := append(converter.Process(input[:20000]), converter.Process(input[20000:])...)
+
+	if math.Abs(float64(len(output)-SampleRate())) > 1 {
+		t.Fatalf("Expected approximately %d output samples, but got %d", SampleRate(), len(output))
+	}
+	checkSineWave(t, output, SampleRate())
+}
+
+func TestConverterInvalid(t *testing.T) {
+	if _, err := NewConverter(0, 1); err == nil {
+		t.Fatalf("Expected an error for a sample rate of 0.")
+	}
+	if _, err := NewConverter(48000, 0); err == nil {
+		t.Fatalf("Expected an error for a channel count of 0.")
+	}
+}
+
+func TestDownmixToMono(t *testing.T) {
+	tests := []struct {
+		name        string
+		interleaved []int16
+		channels    int
+		expected    []int16
+	}{
+		{"stereo", []int16{100, 200, -100, -300, 32767, 32767}, 2, []int16{150, -200, 32767}},
+		{"stereo odd length", []int16{100, 200, 300}, 2, []int16{150}},
+		{"three channels", []int16{1, 2, 3, -32768, -32768, -32768}, 3, []int16{2, -32768}},
+		{"mono", []int16{1, 2, 3}, 1, []int16{1, 2, 3}},
+		{"invalid channels", []int16{1, 2}, 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mono := DownmixToMono(tt.interleaved, tt.channels)
+			if len(mono) != len(tt.expected) {
+				t.Fatalf("Expected %v, but got %v", tt.expected, mono)
+			}
+			for i := range mono {
+				if mono[i] != tt.expected[i] {
+					t.Fatalf("Expected %v, but got %v", tt.expected, mono)
+				}
+			}
+		})
+	}
+
+	checkSineWave(t, DownmixToMono(sineWave(16000, 1600, 2), 2), 16000)
+}
+
+func TestResample(t *testing.T) {
+	input := sineWave(48000, 48000, 1)
+	output, err := Resample(input, 48000)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(output) != SampleRate() {
+		t.Fatalf("Expected %d output samples, but got %d", SampleRate(), len(output))
+	}
+	checkSineWave(t, output, SampleRate())
+
+	// streaming in chunks gives the same result as resampling at once
+	resampler, err := NewResampler(48000)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	var streamed []int16
+	for start := 0; start < len(input); start += 997 {
+		end := start + 997
+		if end > len(input) {
+			end = len(input)
+		}
+		streamed = append(streamed, resampler.Process(input[start:end])...)
+	}
+	if len(streamed) != len(output) {
+		t.Fatalf("Expected %d samples from streaming, but got %d", len(output), len(streamed))
+	}
+	for i := range output {
+		if streamed[i] != output[i] {
+			t.Fatalf("Sample %d differs between streaming (%d) and one-shot (%d) resampling", i, streamed[i], output[i])
+		}
+	}
+
+	if _, err := Resample(input, 0); err == nil {
+		t.Fatalf("Expected an error for a sample rate of 0.")
+	}
+}
+
+func TestDecodePCM(t *testing.T) {
+	data := []byte{0x01, 0x02, 0xff, 0x7f}
+	pcm := make([]int16, 2)
+
+	decodePCM(pcm, data, binary.LittleEndian)
+	if pcm[0] != 0x0201 || pcm[1] != 0x7fff {
+		t.Fatalf("Expected [%d %d] from little-endian bytes, but got %v", 0x0201, 0x7fff, pcm)
+	}
+
+	decodePCM(pcm, data, binary.BigEndian)
+	if pcm[0] != 0x0102 || pcm[1] != -129 {
+		t.Fatalf("Expected [%d %d] from big-endian bytes, but got %v", 0x0102, -129, pcm)
+	}
+}
+
+func TestBytesToInt16LE(t *testing.T) {
+	// samples whose bytes differ, so that decoding in the host's byte order on a big-endian host, e.g. by
+	// reinterpreting the bytes with unsafe, would give different values
+	samples := []int16{0x0102, -129, math.MinInt16, math.MaxInt16, 0, -1}
+	data := []byte{0x02, 0x01, 0x7f, 0xff, 0x00, 0x80, 0xff, 0x7f, 0x00, 0x00, 0xff, 0xff}
+
+	if b := Int16ToBytesLE(samples); !bytes.Equal(b, data) {
+		t.Fatalf("Expected little-endian bytes %x, but got %x", data, b)
+	}
+
+	pcm, err := BytesToInt16LE(data)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if !reflect.DeepEqual(pcm, samples) {
+		t.Fatalf("Expected samples %v, but got %v", samples, pcm)
+	}
+
+	bigEndian := make([]int16, len(samples))
+	decodePCM(bigEndian, data, binary.BigEndian)
+	if reflect.DeepEqual(bigEndian, pcm) {
+		t.Fatalf("Expected the samples to differ from a big-endian reading of the bytes, %v", bigEndian)
+	}
+
+	if _, err := BytesToInt16LE(data[:3]); err == nil {
+		t.Fatalf("Expected an error for an odd number of bytes.")
+	}
+	if pcm, err := BytesToInt16LE(nil); err != nil || len(pcm) != 0 {
+		t.Fatalf("Expected no samples for no bytes, but got %v, %v", pcm, err)
+	}
+}
+
+func TestRMSLevel(t *testing.T) {
+	if level := rmsLevel(make([]int16, 512)); level != 0 {
+		t.Fatalf("Expected level 0 for silence, but got %f", level)
+	}
+
+	fullScale := make([]int16, 512)
+	for i := range fullScale {
+		fullScale[i] = math.MinInt16
+	}
+	if level := rmsLevel(fullScale); level != 1 {
+		t.Fatalf("Expected level 1 for full scale, but got %f", level)
+	}
+}
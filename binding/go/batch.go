@@ -0,0 +1,101 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package porcupine
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Scans a WAV file for keywords and returns the detections in order. The file must contain single-channel,
+// 16-bit PCM at `SampleRate()`; other formats are rejected before any audio is processed. Detection timestamps
+// and frame indices are relative to the start of the file. Cancelling `ctx` stops the scan between frames and
+// returns the detections found so far along with `ctx.Err()`.
+func (porcupine *Porcupine) ProcessFile(ctx context.Context, path string) ([]Detection, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := readWAVHeader(bufio.NewReader(f))
+	if err != nil {
+		return nil, err
+	}
+	return porcupine.Drain(ctx, data)
+}
+
+// Reads 16-bit little-endian PCM from `r` frame by frame until EOF and returns the detections in order.
+// Detection timestamps are relative to the first sample read. A trailing partial frame is ignored. Cancelling
+// `ctx` stops reading between frames and returns the detections found so far along with `ctx.Err()`.
+func (porcupine *Porcupine) Drain(ctx context.Context, r io.Reader) ([]Detection, error) {
+	var detections []Detection
+	frameBytes := make([]byte, porcupine.frameLength*2)
+	frame := make([]int16, porcupine.frameLength)
+	for frameIndex := int64(0); ; frameIndex++ {
+		if err := ctx.Err(); err != nil {
+			return detections, err
+		}
+
+		if _, err := io.ReadFull(r, frameBytes); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return detections, nil
+			}
+			return detections, err
+		}
+
+		keywordIndex, err := porcupine.ProcessBytesInto(frameBytes, frame)
+		if err != nil {
+			return detections, err
+		}
+		if keywordIndex >= 0 {
+			detections = append(detections, porcupine.newDetection(keywordIndex, frameIndex))
+		}
+	}
+}
+
+// Scans every `.wav` file in `dir` (not including subdirectories) with `ProcessFile` and returns the
+// detections keyed by file path. Files are processed one after another on the same instance, which is `Reset`
+// before each file so that no detection state, such as a `MinDetectionGap` cooldown, carries over from one file to
+// the next. Cancelling `ctx` stops the scan and returns the results gathered so far along with `ctx.Err()`.
+func (porcupine *Porcupine) ProcessDir(ctx context.Context, dir string) (map[string][]Detection, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string][]Detection)
+	for _, file := range files {
+		if file.IsDir() || !strings.EqualFold(filepath.Ext(file.Name()), ".wav") {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+
+		if err := porcupine.Reset(); err != nil {
+			return results, err
+		}
+		path := filepath.Join(dir, file.Name())
+		detections, err := porcupine.ProcessFile(ctx, path)
+		if err != nil {
+			return results, err
+		}
+		results[path] = detections
+	}
+	return results, nil
+}
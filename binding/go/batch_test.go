@@ -0,0 +1,186 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porcupine
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestProcessFile(t *testing.T) {
+	requireNativeLibrary(t)
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/porcupine.wav")
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	err := p.Init()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	detections, err := p.ProcessFile(context.Background(), test_file)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(detections) != 1 || detections[0].Keyword != string(PORCUPINE) {
+		t.Fatalf("Expected a single detection of '%s', but got %v", PORCUPINE, detections)
+	}
+	t.Logf("Keyword triggered at %v", detections[0].Timestamp)
+}
+
+func TestProcessFileCancel(t *testing.T) {
+	test_file, _ := filepath.Abs("../../resources/audio_samples/porcupine.wav")
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	err := p.Init()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	detections, err := p.ProcessFile(ctx, test_file)
+	if err != context.Canceled {
+		t.Fatalf("Expected %v, but got %v", context.Canceled, err)
+	}
+	if len(detections) != 0 {
+		t.Fatalf("Expected no detections from a cancelled scan, but got %v", detections)
+	}
+}
+
+func TestProcessDir(t *testing.T) {
+	requireNativeLibrary(t)
+
+	test_dir, _ := filepath.Abs("../../resources/audio_samples")
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	err := p.Init()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	results, err := p.ProcessDir(context.Background(), test_dir)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	expectedCounts := map[string]int{"porcupine.wav": 1, "multiple_keywords.wav": 2}
+	for name, count := range expectedCounts {
+		detections, ok := results[filepath.Join(test_dir, name)]
+		if !ok {
+			t.Fatalf("No results for %s", name)
+		}
+		if len(detections) != count {
+			t.Fatalf("Expected %d detections in %s, but got %d", count, name, len(detections))
+		}
+	}
+}
+
+func TestProcessDirResetsBetweenFiles(t *testing.T) {
+	requireNativeLibrary(t)
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/porcupine.wav")
+	data, err := ioutil.ReadFile(test_file)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"a.wav", "b.wav"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+
+	// the keyword is near the end of the file, so a cooldown carried over would suppress it in the next file
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}, MinDetectionGap: time.Minute}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	results, err := p.ProcessDir(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	first, second := results[filepath.Join(dir, "a.wav")], results[filepath.Join(dir, "b.wav")]
+	if len(first) != 1 || !reflect.DeepEqual(first, second) {
+		t.Fatalf("Expected identical files to have identical detections, but got %v and %v", first, second)
+	}
+}
+
+// cancels a context once a given number of bytes has been read
+type cancellingReader struct {
+	r      io.Reader
+	cancel context.CancelFunc
+	after  int
+	read   int
+}
+
+func (c *cancellingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.read += n
+	if c.read >= c.after {
+		c.cancel()
+	}
+	return n, err
+}
+
+func TestDrainCancelled(t *testing.T) {
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/multiple_keywords.wav")
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	err := p.Init()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	f, err := os.Open(test_file)
+	if err != nil {
+		t.Fatalf("Could not read test file: %v", err)
+	}
+	defer f.Close()
+
+	data, err := readWAVHeader(bufio.NewReader(f))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// cancel after roughly one second of audio
+	reader := &cancellingReader{r: data, cancel: cancel, after: SampleRate() * 2}
+
+	start := time.Now()
+	detections, err := p.Drain(ctx, reader)
+	if err != context.Canceled {
+		t.Fatalf("Expected %v, but got %v", context.Canceled, err)
+	}
+	if len(detections) != 0 {
+		t.Fatalf("Expected no detections in the first second, but got %v", detections)
+	}
+	if reader.read > SampleRate()*2+FrameLength()*2+4096 {
+		t.Fatalf("Expected reading to stop promptly after cancellation, but read %d bytes", reader.read)
+	}
+	t.Logf("Returned %v after cancellation", time.Since(start))
+}
@@ -9,6 +9,10 @@ cp ../../lib/mac/x86_64/libpv_porcupine.dylib ./embedded/lib/mac/x86_64/libpv_po
 
 echo "Copying Windows lib..."
 cp ../../lib/windows/amd64/libpv_porcupine.dll ./embedded/lib/windows/amd64/libpv_porcupine.dll
+if [ -d ../../lib/windows/x86 ]; then
+  mkdir -p ./embedded/lib/windows/x86
+  cp ../../lib/windows/x86/libpv_porcupine.dll ./embedded/lib/windows/x86/libpv_porcupine.dll
+fi
 
 echo "Copying RPi libs..."
 cp -rp ../../lib/raspberry-pi/* ./embedded/lib/raspberry-pi
@@ -19,4 +23,7 @@ cp -rp ../../resources/keyword_files/mac/* ./embedded/resources/keyword_files/ma
 cp -rp ../../resources/keyword_files/linux/* ./embedded/resources/keyword_files/linux
 cp -rp ../../resources/keyword_files/raspberry-pi/* ./embedded/resources/keyword_files/raspberry-pi
 
+echo "Copying self-test audio"
+cp ../../resources/audio_samples/porcupine.wav ./embedded/resources/audio_samples/porcupine.wav
+
 echo "Copy complete!"
\ No newline at end of file
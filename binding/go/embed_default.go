@@ -0,0 +1,25 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+//go:build !porcupine_noembed
+// +build !porcupine_noembed
+
+package porcupine
+
+import (
+	"embed"
+)
+
+// Whether the library, model and keyword files are embedded. See embed_none.go.
+const embeddedAssets = true
+
+//go:embed embedded
+var embeddedFS embed.FS
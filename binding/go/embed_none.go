@@ -0,0 +1,27 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+//go:build porcupine_noembed
+// +build porcupine_noembed
+
+package porcupine
+
+import (
+	"embed"
+)
+
+// Building with the porcupine_noembed tag leaves out the embedded library, model and keyword files, which saves
+// several MB per binary. The library, model and keyword files must then be given explicitly with LibraryPath (or
+// SetLibraryPath), ModelPath and KeywordPaths, and built-in keywords are not available.
+const embeddedAssets = false
+
+// empty file system in place of the embedded files
+var embeddedFS embed.FS
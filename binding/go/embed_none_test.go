@@ -0,0 +1,66 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build porcupine_noembed
+// +build porcupine_noembed
+
+package porcupine
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Run with `go test -tags porcupine_noembed -run NoEmbed`; the other tests need the embedded files.
+func TestNoEmbedRequiresPaths(t *testing.T) {
+	libPath := registerTestNative(t, &testNative{version: embeddedAssetsVersion})
+	modelPath := filepath.Join(t.TempDir(), "porcupine_params.pv")
+	if err := ioutil.WriteFile(modelPath, []byte("model"), 0644); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	tests := []struct {
+		name      string
+		porcupine *Porcupine
+		expected  string
+	}{
+		{"missing library path", &Porcupine{
+			ModelPath:    "/path/to/porcupine_params.pv",
+			KeywordPaths: []string{"/path/to/keyword.ppn"}}, "LibraryPath"},
+		{"built-in keyword", &Porcupine{
+			LibraryPath:     libPath,
+			ModelPath:       modelPath,
+			BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}, "not available"},
+		{"missing model path", &Porcupine{
+			LibraryPath:  libPath,
+			KeywordPaths: []string{"/path/to/keyword.ppn"}}, "ModelPath must be set"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.porcupine.LibraryPath == "" && usingFakeNative {
+				t.Skip("the fake native library doesn't need a library path")
+			}
+			err := tt.porcupine.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Fatalf("Expected an error containing '%s', but got: %v", tt.expected, err)
+			}
+		})
+	}
+
+	if _, err := BuiltInKeywordsForModel("/path/to/porcupine_params.pv"); err == nil {
+		t.Fatalf("Expected an error listing built-in keywords without embedded files.")
+	}
+	if languages := SupportedLanguages(); len(languages) != 0 {
+		t.Fatalf("Expected no supported languages without embedded files, but got %v", languages)
+	}
+}
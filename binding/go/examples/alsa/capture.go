@@ -0,0 +1,98 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+//go:build linux
+// +build linux
+
+package main
+
+/*
+#cgo LDFLAGS: -lasound
+
+#include <stdlib.h>
+#include <alsa/asoundlib.h>
+
+// Opens a capture device for 16-bit little-endian mono audio at `sample_rate`.
+static int open_capture(snd_pcm_t **pcm, const char *device, unsigned int sample_rate) {
+	int err = snd_pcm_open(pcm, device, SND_PCM_STREAM_CAPTURE, 0);
+	if (err < 0) {
+		return err;
+	}
+	err = snd_pcm_set_params(*pcm, SND_PCM_FORMAT_S16_LE, SND_PCM_ACCESS_RW_INTERLEAVED, 1, sample_rate, 1, 500000);
+	if (err < 0) {
+		snd_pcm_close(*pcm);
+		*pcm = NULL;
+	}
+	return err;
+}
+
+// Reads a full frame, recovering from overruns. Returns 0 on success or a negative error code.
+static int read_frame(snd_pcm_t *pcm, int16_t *frame, snd_pcm_uframes_t frame_length) {
+	snd_pcm_uframes_t read = 0;
+	while (read < frame_length) {
+		snd_pcm_sframes_t n = snd_pcm_readi(pcm, frame + read, frame_length - read);
+		if (n < 0) {
+			int err = snd_pcm_recover(pcm, (int) n, 1);
+			if (err < 0) {
+				return err;
+			}
+			continue;
+		}
+		read += (snd_pcm_uframes_t) n;
+	}
+	return 0;
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// captureDevice reads audio from an ALSA capture device. It implements porcupine.FrameSource.
+type captureDevice struct {
+	name string
+	pcm  *C.snd_pcm_t
+}
+
+func openCaptureDevice(name string, sampleRate int) (*captureDevice, error) {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	device := &captureDevice{name: name}
+	if err := C.open_capture(&device.pcm, cName, C.uint(sampleRate)); err < 0 {
+		switch -err {
+		case C.EBUSY:
+			return nil, fmt.Errorf("capture device '%s' is busy. Close other applications using it and try again", name)
+		case C.ENOENT, C.ENODEV:
+			return nil, fmt.Errorf("capture device '%s' does not exist", name)
+		case C.EINVAL:
+			return nil, fmt.Errorf("capture device '%s' does not support 16-bit mono audio at %dHz. "+
+				"Try the 'plughw' or 'default' device, which convert the format", name, sampleRate)
+		default:
+			return nil, fmt.Errorf("failed to open capture device '%s': %s", name, C.GoString(C.snd_strerror(err)))
+		}
+	}
+	return device, nil
+}
+
+func (device *captureDevice) ReadFrame(frame []int16) error {
+	err := C.read_frame(device.pcm, (*C.int16_t)(unsafe.Pointer(&frame[0])), C.snd_pcm_uframes_t(len(frame)))
+	if err < 0 {
+		return fmt.Errorf("failed to read from capture device '%s': %s", device.name, C.GoString(C.snd_strerror(err)))
+	}
+	return nil
+}
+
+func (device *captureDevice) Close() {
+	C.snd_pcm_close(device.pcm)
+}
@@ -0,0 +1,7 @@
+module github.com/Picovoice/porcupine/binding/go/examples/alsa
+
+go 1.16
+
+require github.com/Picovoice/porcupine/binding/go v1.9.0
+
+replace github.com/Picovoice/porcupine/binding/go => ../../
@@ -0,0 +1,64 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+//go:build linux
+// +build linux
+
+// Command alsa listens for wake words on an ALSA capture device. It is a minimal example for Linux appliances
+// and single-board computers that opens the device directly through libasound, with no other audio dependencies.
+//
+// Building it requires the ALSA development headers (e.g. `sudo apt install libasound2-dev`):
+//
+//	go run . -keywords porcupine,bumblebee
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+
+	porcupine "github.com/Picovoice/porcupine/binding/go"
+)
+
+func main() {
+	deviceArg := flag.String("device", "default", "ALSA capture device, e.g. 'default' or 'plughw:1,0'")
+	keywordsArg := flag.String("keywords", "porcupine", "Comma-separated list of built-in keywords")
+	flag.Parse()
+
+	p := porcupine.Porcupine{}
+	for _, keyword := range strings.Split(*keywordsArg, ",") {
+		p.BuiltInKeywords = append(p.BuiltInKeywords, porcupine.BuiltInKeyword(strings.TrimSpace(keyword)))
+	}
+	if err := p.Init(); err != nil {
+		log.Fatalf("Failed to initialize Porcupine: %v", err)
+	}
+	defer p.Delete()
+
+	device, err := openCaptureDevice(*deviceArg, porcupine.SampleRate())
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	defer device.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	log.Printf("Listening on '%s' for %s... Press Ctrl+C to exit.", *deviceArg, *keywordsArg)
+	err = p.Run(ctx, device, func(detection porcupine.Detection) {
+		log.Printf("Detected '%s'", detection.Keyword)
+	})
+	if err != nil && err != context.Canceled {
+		log.Fatalf("%v", err)
+	}
+}
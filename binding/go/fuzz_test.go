@@ -0,0 +1,64 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.18 && !porcupine_noembed
+// +build go1.18,!porcupine_noembed
+
+package porcupine
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// Feeds arbitrary input to the Process functions. Input of any length must either be processed or rejected with
+// an error; nothing may panic or reach the native library with a frame of the wrong size. Needs the embedded
+// library, so it isn't built with the porcupine_noembed tag. Run with
+//
+//	go test -fuzz FuzzProcess
+func FuzzProcess(f *testing.F) {
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	if err := p.Init(); err != nil {
+		f.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	var deleted Porcupine
+	frameBytes := FrameLength() * 2
+	for _, size := range []int{0, 1, 2, frameBytes - 2, frameBytes - 1, frameBytes, frameBytes + 1, frameBytes * 2} {
+		f.Add(make([]byte, size))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		pcm := make([]int16, len(data)/2)
+		decodePCM(pcm, data, binary.LittleEndian)
+		floats := make([]float32, len(data)/4)
+		for i := range floats {
+			floats[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+		}
+
+		if keywordIndex, err := p.Process(pcm); (err == nil) != (len(pcm) == FrameLength()) || keywordIndex < -1 {
+			t.Fatalf("Unexpected result %d, %v for a frame of %d samples", keywordIndex, err, len(pcm))
+		}
+		if _, err := p.Process(nil); err == nil {
+			t.Fatalf("Expected an error for a nil frame.")
+		}
+		if _, err := p.ProcessBytes(data); (err == nil) != (len(data) == frameBytes) {
+			t.Fatalf("Unexpected error %v for a frame of %d bytes", err, len(data))
+		}
+		if _, err := p.ProcessFloat32(floats); (err == nil) != (len(floats) == FrameLength()) {
+			t.Fatalf("Unexpected error %v for a frame of %d float samples", err, len(floats))
+		}
+		if _, err := deleted.Process(pcm); err == nil {
+			t.Fatalf("Expected an error from an instance that was not initialized.")
+		}
+	})
+}
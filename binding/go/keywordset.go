@@ -0,0 +1,121 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package porcupine
+
+import "encoding/json"
+
+// KeywordSet struct
+type KeywordSet struct {
+	entries []keywordSetEntry
+}
+
+type keywordSetEntry struct {
+	label       string
+	builtIn     BuiltInKeyword
+	path        string
+	sensitivity float32
+}
+
+// JSON encoding of a keyword set entry. Labels are derived from the keyword when decoding, so they aren't encoded.
+type keywordSetEntryJSON struct {
+	BuiltIn     BuiltInKeyword `json:"builtin,omitempty"`
+	Path        string         `json:"path,omitempty"`
+	Sensitivity float32        `json:"sensitivity"`
+}
+
+// Creates an empty keyword set. Keywords are detected with the index they were added at, so index `i` returned
+// by `Process` is the `i`th keyword added, whether built-in or custom.
+func NewKeywordSet() *KeywordSet {
+	return &KeywordSet{}
+}
+
+// Adds a built-in keyword, labeled with its name.
+func (set *KeywordSet) AddBuiltIn(keyword BuiltInKeyword, sensitivity float32) *KeywordSet {
+	set.entries = append(set.entries, keywordSetEntry{label: string(keyword), builtIn: keyword, sensitivity: sensitivity})
+	return set
+}
+
+// Adds a keyword file (.ppn), labeled with its file name without extension.
+func (set *KeywordSet) AddCustom(path string, sensitivity float32) *KeywordSet {
+	set.entries = append(set.entries, keywordSetEntry{label: keywordLabelFromPath(path), path: path, sensitivity: sensitivity})
+	return set
+}
+
+// Returns the labels of the keywords in the order they were added.
+func (set *KeywordSet) Labels() []string {
+	labels := make([]string, len(set.entries))
+	for i, entry := range set.entries {
+		labels[i] = entry.label
+	}
+	return labels
+}
+
+// Returns the number of keywords in the set.
+func (set *KeywordSet) Len() int {
+	return len(set.entries)
+}
+
+// Encodes the keywords in the order they were added, e.g. for `SaveState`.
+func (set *KeywordSet) MarshalJSON() ([]byte, error) {
+	entries := make([]keywordSetEntryJSON, len(set.entries))
+	for i, entry := range set.entries {
+		entries[i] = keywordSetEntryJSON{BuiltIn: entry.builtIn, Path: entry.path, Sensitivity: entry.sensitivity}
+	}
+	return json.Marshal(entries)
+}
+
+// Decodes keywords encoded by `MarshalJSON`. Each entry must name either a built-in keyword or a keyword file.
+func (set *KeywordSet) UnmarshalJSON(data []byte) error {
+	var entries []keywordSetEntryJSON
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	decoded := NewKeywordSet()
+	for _, entry := range entries {
+		switch {
+		case entry.BuiltIn != "" && entry.Path == "":
+			decoded.AddBuiltIn(entry.BuiltIn, entry.Sensitivity)
+		case entry.BuiltIn == "" && entry.Path != "":
+			decoded.AddCustom(entry.Path, entry.Sensitivity)
+		default:
+			return newPorcupineError(INVALID_ARGUMENT, "Keyword set entry must have either a built-in keyword or a "+
+				"path, but got '%s' and '%s'", entry.BuiltIn, entry.Path)
+		}
+	}
+	*set = *decoded
+	return nil
+}
+
+// Returns a copy of the set with the sensitivities replaced, in the order the keywords were added.
+func (set *KeywordSet) withSensitivities(sensitivities []float32) *KeywordSet {
+	updated := &KeywordSet{entries: append([]keywordSetEntry(nil), set.entries...)}
+	for i := range updated.entries {
+		updated.entries[i].sensitivity = sensitivities[i]
+	}
+	return updated
+}
+
+// Returns the keyword paths, labels and sensitivities in the order the keywords were added.
+func (set *KeywordSet) resolve(assets *languageAssets) (keywordPaths []string, keywordLabels []string, sensitivities []float32, err error) {
+	for _, entry := range set.entries {
+		keywordPath := entry.path
+		if entry.builtIn != "" {
+			if keywordPath, err = assets.builtInKeywordPath(entry.builtIn); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+		keywordPaths = append(keywordPaths, keywordPath)
+		keywordLabels = append(keywordLabels, entry.label)
+		sensitivities = append(sensitivities, entry.sensitivity)
+	}
+	return keywordPaths, keywordLabels, sensitivities, nil
+}
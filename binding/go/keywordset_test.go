@@ -0,0 +1,86 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porcupine
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestKeywordSet(t *testing.T) {
+	requireNativeLibrary(t)
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/multiple_keywords.wav")
+	alexaPath := testKeywordFile(t, ALEXA)
+
+	set := NewKeywordSet().
+		AddBuiltIn(PORCUPINE, 0.5).
+		AddCustom(alexaPath, 0.6)
+	expectedLabels := []string{string(PORCUPINE), keywordLabelFromPath(alexaPath)}
+	if !reflect.DeepEqual(set.Labels(), expectedLabels) || set.Len() != 2 {
+		t.Fatalf("Expected labels %v, but got %v", expectedLabels, set.Labels())
+	}
+
+	p := Porcupine{KeywordSet: set}
+	err := p.Init()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	if !reflect.DeepEqual(p.sensitivities, []float32{0.5, 0.6}) {
+		t.Fatalf("Expected sensitivities in the order of the set, but got %v", p.sensitivities)
+	}
+
+	detections, err := p.ProcessFile(context.Background(), test_file)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	var indices []int
+	for _, detection := range detections {
+		if detection.Keyword != expectedLabels[detection.Index] {
+			t.Fatalf("Expected label '%s' for index %d, but got '%s'", expectedLabels[detection.Index],
+				detection.Index, detection.Keyword)
+		}
+		indices = append(indices, detection.Index)
+	}
+	if !reflect.DeepEqual(indices, []int{0, 1, 0}) {
+		t.Fatalf("Expected detections [0 1 0], but got %v", indices)
+	}
+}
+
+func TestKeywordSetInvalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		porcupine *Porcupine
+	}{
+		{"empty set", &Porcupine{KeywordSet: NewKeywordSet()}},
+		{"combined with built-in keywords", &Porcupine{
+			KeywordSet:      NewKeywordSet().AddBuiltIn(PORCUPINE, 0.5),
+			BuiltInKeywords: []BuiltInKeyword{ALEXA}}},
+		{"combined with sensitivities", &Porcupine{
+			KeywordSet:    NewKeywordSet().AddBuiltIn(PORCUPINE, 0.5),
+			Sensitivities: []float32{0.5}}},
+		{"invalid built-in keyword", &Porcupine{KeywordSet: NewKeywordSet().AddBuiltIn("not a keyword", 0.5)}},
+		{"missing keyword file", &Porcupine{KeywordSet: NewKeywordSet().AddCustom("/does/not/exist.ppn", 0.5)}},
+		{"sensitivity out of range", &Porcupine{KeywordSet: NewKeywordSet().AddBuiltIn(PORCUPINE, 1.5)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.porcupine.Validate(); err == nil {
+				t.Fatalf("Expected a validation error.")
+			}
+		})
+	}
+}
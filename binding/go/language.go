@@ -0,0 +1,316 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package porcupine
+
+import (
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Language type
+type Language string
+
+// Available languages
+const (
+	ENGLISH Language = "en"
+	FRENCH  Language = "fr"
+	GERMAN  Language = "de"
+	SPANISH Language = "es"
+)
+
+// Checks if a given Language is valid
+func (l Language) IsValid() bool {
+	switch l {
+	case ENGLISH, FRENCH, GERMAN, SPANISH:
+		return true
+	}
+	return false
+}
+
+// Model and keyword files of non-English languages are suffixed with the language code, e.g.
+// `porcupine_params_de.pv` and `keyword_files_de`.
+func (l Language) assetSuffix() string {
+	if l == ENGLISH || l == "" {
+		return ""
+	}
+	return "_" + string(l)
+}
+
+// Returns the built-in keywords that can be used with a model, based on the language in the model's file name:
+// `porcupine_params.pv` is English and `porcupine_params_<language>.pv` is the given language, e.g.
+// `porcupine_params_de.pv` for German. Non-English keywords are only available if the language is embedded in
+// the build.
+func BuiltInKeywordsForModel(modelPath string) ([]BuiltInKeyword, error) {
+	language, err := languageFromModelPath(modelPath)
+	if err != nil {
+		return nil, err
+	}
+	if !embeddedAssets {
+		return nil, newPorcupineError(INVALID_ARGUMENT, "Built-in keywords are not available in builds with the "+
+			"porcupine_noembed tag.")
+	}
+	if language == ENGLISH {
+		return append([]BuiltInKeyword(nil), BuiltInKeywords...), nil
+	}
+
+	if err := loadPorcupine(); err != nil {
+		return nil, err
+	}
+	assets, err := getLanguageAssets(language)
+	if err != nil {
+		return nil, err
+	}
+
+	keywords := make([]BuiltInKeyword, 0, len(assets.keywordPaths))
+	for keyword := range assets.keywordPaths {
+		keywords = append(keywords, BuiltInKeyword(keyword))
+	}
+	sort.Slice(keywords, func(i, j int) bool { return keywords[i] < keywords[j] })
+	return keywords, nil
+}
+
+// Returns the language of the model the instance was initialized with, e.g. "en" for the bundled model. The native
+// library does not report the language of a model, so it is derived from the model's file name. If the file name
+// doesn't follow the `porcupine_params[_<language>].pv` convention, the configured `Language` is returned, or ""
+// if none was set. Returns "" if the instance has not been initialized.
+func (porcupine *Porcupine) ModelLanguage() string {
+	if porcupine.modelPath == "" {
+		return ""
+	}
+	if language, err := languageFromModelPath(porcupine.modelPath); err == nil {
+		return string(language)
+	}
+	return string(porcupine.Language)
+}
+
+// Returns the language of a model from its file name.
+func languageFromModelPath(modelPath string) (Language, error) {
+	name := strings.TrimSuffix(filepath.Base(modelPath), ".pv")
+	if name == "porcupine_params" {
+		return ENGLISH, nil
+	}
+
+	language := Language(strings.TrimPrefix(name, "porcupine_params_"))
+	if !strings.HasPrefix(name, "porcupine_params_") || !language.IsValid() {
+		return "", newPorcupineError(INVALID_ARGUMENT, "Could not determine the language of model '%s'. Model files "+
+			"are named porcupine_params.pv for English and porcupine_params_<language>.pv otherwise.", modelPath)
+	}
+	return language, nil
+}
+
+// Returns the codes of the languages whose models are embedded in the build, sorted, e.g. ["de" "en"]. Model
+// files are named `porcupine_params.pv` for English and `porcupine_params_<language>.pv` otherwise. Returns an
+// empty list in builds with the porcupine_noembed tag.
+func SupportedLanguages() []string {
+	return modelLanguages(embeddedFS, "embedded/lib/common")
+}
+
+// Returns the languages of the model files in a directory, sorted.
+func modelLanguages(fsys fs.FS, dir string) []string {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return []string{}
+	}
+
+	languages := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, "porcupine_params") || !strings.HasSuffix(name, ".pv") {
+			continue
+		}
+		suffix := strings.TrimSuffix(strings.TrimPrefix(name, "porcupine_params"), ".pv")
+		switch {
+		case suffix == "":
+			languages = append(languages, string(ENGLISH))
+		case strings.HasPrefix(suffix, "_") && len(suffix) > 1:
+			languages = append(languages, suffix[1:])
+		}
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// Reports whether `keyword` is a built-in keyword of a non-English language embedded in the build.
+func isEmbeddedLanguageKeyword(keyword BuiltInKeyword) bool {
+	for _, language := range []Language{FRENCH, GERMAN, SPANISH} {
+		platforms, err := embeddedFS.ReadDir("embedded/resources/keyword_files" + language.assetSuffix())
+		if err != nil {
+			continue
+		}
+		for _, platform := range platforms {
+			keywordFiles, err := embeddedKeywordFiles(language, platform.Name())
+			if err != nil {
+				continue
+			}
+			if _, ok := keywordFiles[string(keyword)]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// extracted model and built-in keyword files of a language
+type languageAssets struct {
+	language     Language
+	modelPath    string
+	keywordPaths map[string]string
+}
+
+// Returns the path of a built-in keyword, or an error if the keyword isn't available for the language.
+func (assets *languageAssets) builtInKeywordPath(keyword BuiltInKeyword) (string, error) {
+	keywordPath, ok := assets.keywordPaths[string(keyword)]
+	if ok && (assets.language != ENGLISH || keyword.IsValid()) {
+		return keywordPath, nil
+	}
+	if keywordPath, ok := registeredKeywordPath(keyword); ok {
+		return keywordPath, nil
+	}
+	if !embeddedAssets {
+		return "", newPorcupineError(INVALID_ARGUMENT, "Built-in keyword '%s' is not available in builds with the "+
+			"porcupine_noembed tag. Set KeywordPaths or use RegisterKeywordDir instead.", keyword)
+	}
+	return "", newPorcupineError(INVALID_ARGUMENT, "'%s' is not a valid built-in keyword for language '%s'.",
+		keyword, assets.language)
+}
+
+var (
+	// keyword files registered with RegisterKeywordDir, keyed by keyword name
+	registeredKeywords      = make(map[string]string)
+	registeredKeywordsMutex sync.Mutex
+)
+
+// Registers the keyword files (.ppn) in `dir`, e.g. of a language pack installed separately from the application,
+// so that they can be selected by name in `BuiltInKeywords` and `KeywordSet.AddBuiltIn` like the keywords bundled
+// with the package. Keywords are named after their files with the extension and a platform suffix such as
+// `_linux` removed, so `hey computer_linux.ppn` registers "hey computer". Bundled keywords take precedence over
+// registered ones of the same name, and registering a keyword again replaces its file. Registered keywords are
+// available for every language; they must be used with a model of their language. Returns an error if the
+// directory can't be read, holds no keyword files or one of them can't be read, in which case nothing is
+// registered.
+func RegisterKeywordDir(dir string) error {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return newPorcupineError(IO_ERROR, "Could not read keyword directory '%s': %v", dir, err)
+	}
+	platform, _ := getOS()
+
+	keywords := make(map[string]string)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".ppn" {
+			continue
+		}
+		keywordPath := filepath.Join(dir, entry.Name())
+		f, err := os.Open(keywordPath)
+		if err != nil {
+			return newPorcupineError(IO_ERROR, "Could not read keyword file '%s': %v", keywordPath, err)
+		}
+		f.Close()
+
+		keywordName := strings.TrimSuffix(entry.Name(), ".ppn")
+		if platform != "" {
+			keywordName = strings.TrimSuffix(keywordName, "_"+platform)
+		}
+		keywords[keywordName] = keywordPath
+	}
+	if len(keywords) == 0 {
+		return newPorcupineError(INVALID_ARGUMENT, "No keyword files (.ppn) found in '%s'.", dir)
+	}
+
+	registeredKeywordsMutex.Lock()
+	defer registeredKeywordsMutex.Unlock()
+	for keywordName, keywordPath := range keywords {
+		registeredKeywords[keywordName] = keywordPath
+	}
+	logf("registered keywords %v from %s", keywordNames(keywords), dir)
+	return nil
+}
+
+// Returns the file of a keyword registered with RegisterKeywordDir.
+func registeredKeywordPath(keyword BuiltInKeyword) (string, bool) {
+	registeredKeywordsMutex.Lock()
+	defer registeredKeywordsMutex.Unlock()
+	keywordPath, ok := registeredKeywords[string(keyword)]
+	return keywordPath, ok
+}
+
+// Returns the keys of a map of keyword files, sorted.
+func keywordNames(keywordFiles map[string]string) []string {
+	names := make([]string, 0, len(keywordFiles))
+	for keywordName := range keywordFiles {
+		names = append(names, keywordName)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Reports whether the model or any of the keyword files is one of the embedded assets.
+func (assets *languageAssets) contains(modelPath string, keywordPaths []string) bool {
+	if modelPath == assets.modelPath {
+		return true
+	}
+	for _, keywordPath := range keywordPaths {
+		for _, assetPath := range assets.keywordPaths {
+			if keywordPath == assetPath {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var (
+	extractedLanguages      = make(map[Language]*languageAssets)
+	extractedLanguagesMutex sync.Mutex
+)
+
+// Returns the model and built-in keyword files of a language, extracting them on first use. English assets are
+// extracted when the package is loaded.
+func getLanguageAssets(language Language) (*languageAssets, error) {
+	if language == "" || language == ENGLISH {
+		return &languageAssets{language: ENGLISH, modelPath: defaultModelFile, keywordPaths: builtinKeywords}, nil
+	}
+	if !language.IsValid() {
+		return nil, newPorcupineError(INVALID_ARGUMENT, "'%s' is not a supported language.", language)
+	}
+
+	extractedLanguagesMutex.Lock()
+	defer extractedLanguagesMutex.Unlock()
+
+	if assets, ok := extractedLanguages[language]; ok {
+		return assets, nil
+	}
+
+	modelFile := "embedded/lib/common/porcupine_params" + language.assetSuffix() + ".pv"
+	if _, err := fs.Stat(embeddedFS, modelFile); err != nil {
+		return nil, newPorcupineError(INVALID_ARGUMENT, "Assets for language '%s' are not embedded in this build. "+
+			"Set ModelPath and KeywordPaths to the files for the language instead.", language)
+	}
+
+	modelPath, err := extractFile(modelFile, getVersionedExtractionDir())
+	if err != nil {
+		return nil, err
+	}
+	keywordPaths, err := extractKeywordFiles(language)
+	if err != nil {
+		return nil, err
+	}
+
+	assets := &languageAssets{language: language, modelPath: modelPath, keywordPaths: keywordPaths}
+	extractedLanguages[language] = assets
+	return assets, nil
+}
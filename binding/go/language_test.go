@@ -0,0 +1,247 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porcupine
+
+import (
+	"encoding/json"
+	"io/fs"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestWithLanguage(t *testing.T) {
+	p, err := NewPorcupine(WithLanguage(ENGLISH), WithBuiltInKeywords(PORCUPINE))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+	if p.modelPath != testModelFile(t) {
+		t.Fatalf("Expected English model '%s', but got '%s'", testModelFile(t), p.modelPath)
+	}
+
+	if _, err := NewPorcupine(WithLanguage("xx")); err == nil {
+		t.Fatalf("Expected an error for an unsupported language.")
+	}
+
+	for _, language := range []Language{FRENCH, GERMAN, SPANISH} {
+		t.Run(string(language), func(t *testing.T) {
+			_, err := fs.Stat(embeddedFS, "embedded/lib/common/porcupine_params_"+string(language)+".pv")
+			embedded := err == nil
+
+			p := Porcupine{Language: language, BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+			err = p.Validate()
+			if !embedded {
+				if err == nil || !strings.Contains(err.Error(), "not embedded") {
+					t.Fatalf("Expected an error for assets that are not embedded, but got: %v", err)
+				}
+				return
+			}
+
+			// English keywords are not valid for other languages
+			if err == nil {
+				t.Fatalf("Expected English keyword to be rejected for language '%s'.", language)
+			}
+			assets, err := getLanguageAssets(language)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			if filepath.Base(assets.modelPath) != "porcupine_params_"+string(language)+".pv" {
+				t.Fatalf("Expected model for language '%s', but got '%s'", language, assets.modelPath)
+			}
+		})
+	}
+}
+
+func TestBuiltInKeywordsForModel(t *testing.T) {
+	keywords, err := BuiltInKeywordsForModel(testModelFile(t))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(keywords) != len(BuiltInKeywords) {
+		t.Fatalf("Expected the English keywords %v, but got %v", BuiltInKeywords, keywords)
+	}
+
+	_, err = fs.Stat(embeddedFS, "embedded/lib/common/porcupine_params_de.pv")
+	germanEmbedded := err == nil
+	keywords, err = BuiltInKeywordsForModel("/path/to/porcupine_params_de.pv")
+	if germanEmbedded {
+		if err != nil || len(keywords) == 0 {
+			t.Fatalf("Expected German keywords, but got %v (%v)", keywords, err)
+		}
+	} else if err == nil {
+		t.Fatalf("Expected an error for a language that is not embedded.")
+	}
+
+	if _, err := BuiltInKeywordsForModel("/path/to/my_model.pv"); err == nil {
+		t.Fatalf("Expected an error for a model of unknown language.")
+	}
+}
+
+func TestModelLanguage(t *testing.T) {
+	var uninitialized Porcupine
+	if uninitialized.ModelLanguage() != "" {
+		t.Fatalf("Expected no language before Init, but got '%s'", uninitialized.ModelLanguage())
+	}
+
+	p, err := NewPorcupine(WithBuiltInKeywords(PORCUPINE))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+	if p.ModelLanguage() != "en" {
+		t.Fatalf("Expected 'en' for the bundled model, but got '%s'", p.ModelLanguage())
+	}
+
+	modelData, err := ioutil.ReadFile(testModelFile(t))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	dir := t.TempDir()
+	tests := []struct {
+		fileName string
+		language Language
+		expected string
+	}{
+		{"porcupine_params_de.pv", "", "de"},
+		{"my_model.pv", "", ""},
+		{"my_model.pv", SPANISH, "es"},
+	}
+	for _, tt := range tests {
+		modelPath := filepath.Join(dir, tt.fileName)
+		if err := ioutil.WriteFile(modelPath, modelData, 0644); err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		p := Porcupine{ModelPath: modelPath, KeywordPaths: []string{testKeywordFile(t, PORCUPINE)}}
+		if err := p.Init(); err != nil {
+			t.Fatalf("%v", err)
+		}
+		// the language only affects which assets are extracted, so set it after Init to test the fallback
+		p.Language = tt.language
+		if p.ModelLanguage() != tt.expected {
+			t.Fatalf("Expected '%s' for model '%s', but got '%s'", tt.expected, tt.fileName, p.ModelLanguage())
+		}
+		p.Delete()
+	}
+}
+
+func TestAvailableKeywordFiles(t *testing.T) {
+	names, err := AvailableKeywordFiles()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if !sort.StringsAreSorted(names) {
+		t.Fatalf("Expected keyword names to be sorted, but got %v", names)
+	}
+
+	for _, keyword := range BuiltInKeywords {
+		i := sort.SearchStrings(names, string(keyword))
+		if i == len(names) || names[i] != string(keyword) {
+			t.Fatalf("Expected a keyword file for '%s' in %v", keyword, names)
+		}
+	}
+}
+
+func TestSupportedLanguages(t *testing.T) {
+	languages := SupportedLanguages()
+	found := false
+	for _, language := range languages {
+		found = found || language == string(ENGLISH)
+	}
+	if !found {
+		t.Fatalf("Expected the embedded languages to include English, but got %v", languages)
+	}
+
+	fsys := fstest.MapFS{
+		"common/porcupine_params.pv":      &fstest.MapFile{Data: []byte("en")},
+		"common/porcupine_params_fr.pv":   &fstest.MapFile{Data: []byte("fr")},
+		"common/porcupine_params_de.pv":   &fstest.MapFile{Data: []byte("de")},
+		"common/porcupine_params_.pv":     &fstest.MapFile{Data: []byte("invalid")},
+		"common/porcupine_params_es.txt":  &fstest.MapFile{Data: []byte("not a model")},
+		"common/cobra_params.pv":          &fstest.MapFile{Data: []byte("another engine")},
+		"common/porcupine_params_it.pv/x": &fstest.MapFile{Data: []byte("directory")},
+	}
+	expected := []string{"de", "en", "fr"}
+	if languages := modelLanguages(fsys, "common"); !reflect.DeepEqual(languages, expected) {
+		t.Fatalf("Expected languages %v, but got %v", expected, languages)
+	}
+	if languages := modelLanguages(fsys, "missing"); len(languages) != 0 {
+		t.Fatalf("Expected no languages for a missing directory, but got %v", languages)
+	}
+}
+
+func TestRegisterKeywordDir(t *testing.T) {
+	platform, err := getOS()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	keywordData, err := ioutil.ReadFile(testKeywordFile(t, PORCUPINE))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"my porcupine_" + platform + ".ppn": keywordData,
+		"dummy.ppn":                         []byte("dummy"),
+		"notes.txt":                         []byte("not a keyword"),
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+	t.Cleanup(func() {
+		registeredKeywordsMutex.Lock()
+		delete(registeredKeywords, "my porcupine")
+		delete(registeredKeywords, "dummy")
+		registeredKeywordsMutex.Unlock()
+	})
+
+	if err := RegisterKeywordDir(filepath.Join(dir, "missing")); err == nil {
+		t.Fatalf("Expected an error for a missing directory.")
+	}
+	if err := RegisterKeywordDir(t.TempDir()); err == nil {
+		t.Fatalf("Expected an error for a directory without keyword files.")
+	}
+	if err := RegisterKeywordDir(dir); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if keywordPath, ok := registeredKeywordPath("dummy"); !ok || keywordPath != filepath.Join(dir, "dummy.ppn") {
+		t.Fatalf("Expected 'dummy' to be registered, but got '%s'", keywordPath)
+	}
+	if _, ok := registeredKeywordPath("notes"); ok {
+		t.Fatalf("Expected files other than .ppn to be ignored.")
+	}
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{"my porcupine", PORCUPINE}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+	if p.keywordPaths[0] != filepath.Join(dir, "my porcupine_"+platform+".ppn") {
+		t.Fatalf("Expected the registered keyword file, but got '%s'", p.keywordPaths[0])
+	}
+	if !reflect.DeepEqual(p.Keywords(), []string{"my porcupine", "porcupine"}) {
+		t.Fatalf("Expected keywords to be labeled by name, but got %v", p.Keywords())
+	}
+
+	var keyword BuiltInKeyword
+	if err := json.Unmarshal([]byte(`"my porcupine"`), &keyword); err != nil {
+		t.Fatalf("Expected registered keywords to be accepted in configuration files, but got: %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package porcupine
+
+import (
+	"sync"
+)
+
+// Logger interface
+type Logger interface {
+	// Logs a diagnostic message. Implemented by *log.Logger from the standard library.
+	Printf(format string, v ...interface{})
+}
+
+var (
+	logger      Logger
+	loggerMutex sync.Mutex
+)
+
+// Routes diagnostic messages, such as which files were extracted and which native library was loaded, to
+// `l`. Pass nil to stop logging. Nothing is logged by default. Errors are always returned to the caller rather
+// than logged, and the package never exits the process.
+func SetLogger(l Logger) {
+	loggerMutex.Lock()
+	defer loggerMutex.Unlock()
+
+	logger = l
+}
+
+func logf(format string, v ...interface{}) {
+	loggerMutex.Lock()
+	l := logger
+	loggerMutex.Unlock()
+
+	if l != nil {
+		l.Printf("porcupine: "+format, v...)
+	}
+}
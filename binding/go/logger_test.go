@@ -0,0 +1,59 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porcupine
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestSetLogger(t *testing.T) {
+	var buf bytes.Buffer
+	SetLogger(log.New(&buf, "", 0))
+	defer SetLogger(nil)
+
+	previousDir := getExtractionDir()
+	t.Cleanup(func() { SetExtractionDir(previousDir) })
+	if err := SetExtractionDir(t.TempDir()); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	logs := buf.String()
+	for _, expected := range []string{"porcupine: extracted ", "porcupine: initialized engine"} {
+		if !strings.Contains(logs, expected) {
+			t.Fatalf("Expected logs to contain '%s', but got:\n%s", expected, logs)
+		}
+	}
+
+	SetLogger(nil)
+	buf.Reset()
+	if err := p.Reset(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	p.Delete()
+	CleanCache()
+	q := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	if err := q.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	q.Delete()
+	if buf.Len() != 0 {
+		t.Fatalf("Expected nothing to be logged without a logger, but got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package porcupine
+
+import (
+	"sort"
+	"sync"
+)
+
+// ModelManager struct
+type ModelManager struct {
+	mutex  sync.Mutex
+	models map[Language]*languageAssets
+}
+
+// Creates a ModelManager with the embedded models and built-in keyword files of `languages` extracted up front, so
+// that `NewInstance` can create instances for any of them without extracting files, e.g. to switch languages when
+// the user changes the locale. Languages whose models aren't embedded can be added with `AddModel`.
+func NewModelManager(languages ...Language) (*ModelManager, error) {
+	manager := &ModelManager{models: make(map[Language]*languageAssets)}
+	if len(languages) == 0 {
+		return manager, nil
+	}
+
+	if !autoExtractionEnabled() {
+		return nil, newPorcupineError(INVALID_ARGUMENT, "Automatic extraction is disabled, so embedded models can't "+
+			"be loaded. Use AddModel instead.")
+	}
+	if err := loadPorcupine(); err != nil {
+		return nil, err
+	}
+	for _, language := range languages {
+		if language == "" {
+			language = ENGLISH
+		}
+		assets, err := getLanguageAssets(language)
+		if err != nil {
+			return nil, err
+		}
+		manager.models[language] = assets
+	}
+	return manager, nil
+}
+
+// Adds an installed model for `language`, e.g. one of a language that isn't embedded in the build, replacing the
+// model of the language if it was already loaded. Keywords of the language are looked up among the keyword files
+// registered with `RegisterKeywordDir`.
+func (m *ModelManager) AddModel(language Language, modelPath string) error {
+	if !language.IsValid() {
+		return newPorcupineError(INVALID_ARGUMENT, "'%s' is not a supported language.", language)
+	}
+	if !fileExists(modelPath) {
+		return newPorcupineError(INVALID_ARGUMENT, "Specified model file could not be found at %s", modelPath)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.models[language] = &languageAssets{language: language, modelPath: modelPath}
+	return nil
+}
+
+// Returns the codes of the languages the manager has models for, sorted.
+func (m *ModelManager) Languages() []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	languages := make([]string, 0, len(m.models))
+	for language := range m.models {
+		languages = append(languages, string(language))
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// Creates and initializes an instance with the model of `lang` that detects the built-in or registered `keywords`
+// of the language, with the index and label of each keyword as given and the default sensitivity of 0.5. The
+// instance is independent of the manager and must be released with `Delete`.
+func (m *ModelManager) NewInstance(lang string, keywords ...string) (*Porcupine, error) {
+	language := Language(lang)
+	if language == "" {
+		language = ENGLISH
+	}
+
+	m.mutex.Lock()
+	assets, ok := m.models[language]
+	m.mutex.Unlock()
+	if !ok {
+		return nil, newPorcupineError(INVALID_ARGUMENT, "No model for language '%s' has been loaded. Available "+
+			"languages: %v.", lang, m.Languages())
+	}
+
+	// keywords are resolved here, so that the instance uses the manager's files even for languages that aren't
+	// embedded
+	set := NewKeywordSet()
+	for _, keyword := range keywords {
+		keywordPath, err := assets.builtInKeywordPath(BuiltInKeyword(keyword))
+		if err != nil {
+			return nil, err
+		}
+		set.entries = append(set.entries, keywordSetEntry{label: keyword, path: keywordPath, sensitivity: 0.5})
+	}
+
+	porcupine := &Porcupine{Language: language, ModelPath: assets.modelPath, KeywordSet: set}
+	if err := porcupine.Init(); err != nil {
+		return nil, err
+	}
+	return porcupine, nil
+}
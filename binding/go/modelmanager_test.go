@@ -0,0 +1,117 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porcupine
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestModelManager(t *testing.T) {
+	requireNativeLibrary(t)
+
+	platform, err := getOS()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	// only the English model is bundled, so the English files stand in for an installed German model
+	dir := t.TempDir()
+	germanModelPath := filepath.Join(dir, "porcupine_params_de.pv")
+	keywordDir := filepath.Join(dir, "keywords")
+	files := map[string]string{
+		germanModelPath: testModelFile(t),
+		filepath.Join(keywordDir, "stachelschwein_"+platform+".ppn"): testKeywordFile(t, PORCUPINE),
+	}
+	for dst, src := range files {
+		data, err := ioutil.ReadFile(src)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			t.Fatalf("%v", err)
+		}
+		if err := ioutil.WriteFile(dst, data, 0644); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+	if err := RegisterKeywordDir(keywordDir); err != nil {
+		t.Fatalf("%v", err)
+	}
+	t.Cleanup(func() {
+		registeredKeywordsMutex.Lock()
+		delete(registeredKeywords, "stachelschwein")
+		registeredKeywordsMutex.Unlock()
+	})
+
+	manager, err := NewModelManager(ENGLISH)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err := manager.AddModel(GERMAN, filepath.Join(dir, "missing.pv")); err == nil {
+		t.Fatalf("Expected an error for a missing model.")
+	}
+	if err := manager.AddModel(GERMAN, germanModelPath); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if languages := manager.Languages(); !reflect.DeepEqual(languages, []string{"de", "en"}) {
+		t.Fatalf("Expected languages [de en], but got %v", languages)
+	}
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/porcupine.wav")
+	pcm := readTestAudio(t, test_file)
+
+	// switch languages back and forth, as when the user changes the locale
+	tests := []struct {
+		language string
+		keyword  string
+	}{
+		{"en", "porcupine"},
+		{"de", "stachelschwein"},
+		{"en", "porcupine"},
+	}
+	for _, tt := range tests {
+		p, err := manager.NewInstance(tt.language, tt.keyword)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if p.ModelLanguage() != tt.language {
+			p.Delete()
+			t.Fatalf("Expected an instance for language %s, but got %s", tt.language, p.ModelLanguage())
+		}
+
+		var labels []string
+		for i := 0; i+FrameLength() <= len(pcm); i += FrameLength() {
+			label, err := p.ProcessLabel(pcm[i : i+FrameLength()])
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			if label != "" {
+				labels = append(labels, label)
+			}
+		}
+		p.Delete()
+
+		if !reflect.DeepEqual(labels, []string{tt.keyword}) {
+			t.Fatalf("Expected a single detection of '%s' in %s, but got %v", tt.keyword, tt.language, labels)
+		}
+	}
+
+	if _, err := manager.NewInstance("fr", "porcupine"); err == nil {
+		t.Fatalf("Expected an error for a language without a model.")
+	}
+	if _, err := manager.NewInstance("de", "porcupine"); err == nil {
+		t.Fatalf("Expected an error for a keyword that isn't available in the language.")
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+//go:build !porcupine_fake
+// +build !porcupine_fake
+
+package porcupine
+
+// Whether the package was built with the fake native library. See native_fake.go.
+const usingFakeNative = false
+
+// Extracts the embedded native library for the current platform and loads it.
+var loadDefaultLibrary = func() (nativePorcupineInterface, error) {
+	if !embeddedAssets {
+		return nil, newPorcupineError(INVALID_ARGUMENT, "LibraryPath, SetLibraryPath or the PORCUPINE_LIBRARY_PATH "+
+			"environment variable must be set in builds with the porcupine_noembed tag.")
+	}
+
+	var err error
+	if libName, err = extractLib(); err != nil {
+		return nil, err
+	}
+	return getNativeLibrary(libName)
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+//go:build porcupine_fake
+// +build porcupine_fake
+
+// Builds with the porcupine_fake tag replace the native library with a fake written in Go, so that the binding's
+// own logic can be tested on machines and CI runners without the native library:
+//
+//	go test -tags porcupine_fake
+//
+// The fake never detects keywords in real audio. Instead it reports keyword `i` for every frame whose first sample
+// is `fakeDetectionMarker + i`, which lets tests script detections. Libraries loaded through `LibraryPath` are
+// still loaded natively.
+
+package porcupine
+
+import (
+	"os"
+	"unsafe"
+)
+
+const usingFakeNative = true
+
+const fakeDetectionMarker = 30000
+
+var loadDefaultLibrary = func() (nativePorcupineInterface, error) {
+	return &fakeNativeLibrary{}, nil
+}
+
+type fakeNativeLibrary struct{}
+
+// state behind the handle of a fake engine
+type fakeEngine struct {
+	numKeywords int
+}
+
+func (np *fakeNativeLibrary) nativeInit(porcupine *Porcupine) PvStatus {
+	if _, err := os.Stat(porcupine.modelPath); err != nil {
+		return IO_ERROR
+	}
+	for _, keywordPath := range porcupine.keywordPaths {
+		if _, err := os.Stat(keywordPath); err != nil {
+			return IO_ERROR
+		}
+	}
+	if len(porcupine.keywordPaths) == 0 || len(porcupine.keywordPaths) != len(porcupine.sensitivities) {
+		return INVALID_ARGUMENT
+	}
+
+	porcupine.handle = unsafe.Pointer(&fakeEngine{numKeywords: len(porcupine.keywordPaths)})
+	return SUCCESS
+}
+
+func (np *fakeNativeLibrary) nativeProcess(porcupine *Porcupine, pcm []int16) (status PvStatus, keywordIndex int) {
+	engine := (*fakeEngine)(porcupine.handle)
+	index := int(pcm[0]) - fakeDetectionMarker
+	if index >= 0 && index < engine.numKeywords {
+		return SUCCESS, index
+	}
+	return SUCCESS, -1
+}
+
+func (np *fakeNativeLibrary) nativeDelete(porcupine *Porcupine) {
+	porcupine.handle = nil
+}
+
+func (np *fakeNativeLibrary) nativeSampleRate() int {
+	return 16000
+}
+
+func (np *fakeNativeLibrary) nativeFrameLength() int {
+	return 512
+}
+
+func (np *fakeNativeLibrary) nativeVersion() string {
+	return "1.9.0"
+}
+
+func (np *fakeNativeLibrary) nativeErrorStack() []string {
+	return nil
+}
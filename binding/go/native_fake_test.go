@@ -0,0 +1,69 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build porcupine_fake
+// +build porcupine_fake
+
+package porcupine
+
+import (
+	"testing"
+	"time"
+)
+
+// returns frames of silence with the detections of `script` (frame index -> keyword index) marked in them
+func scriptedFrames(numFrames int, script map[int]int) [][]int16 {
+	frames := make([][]int16, numFrames)
+	for i := range frames {
+		frames[i] = make([]int16, FrameLength())
+		if keywordIndex, ok := script[i]; ok {
+			frames[i][0] = int16(fakeDetectionMarker + keywordIndex)
+		}
+	}
+	return frames
+}
+
+func TestFakeScriptedDetections(t *testing.T) {
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{ALEXA, PORCUPINE}, MinDetectionGap: time.Second}
+	err := p.Init()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	// the second detection of ALEXA is within a second of the first and is suppressed
+	script := map[int]int{3: 0, 10: 1, 12: 0, 100: 0}
+	var results []int
+	for _, frame := range scriptedFrames(101, script) {
+		keywordIndex, err := p.Process(frame)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if keywordIndex >= 0 {
+			results = append(results, keywordIndex)
+		}
+	}
+
+	expected := []int{0, 1, 0}
+	if len(results) != len(expected) || results[0] != 0 || results[1] != 1 || results[2] != 0 {
+		t.Fatalf("Expected detections %v, but got %v", expected, results)
+	}
+
+	detections := p.RecentDetections()
+	if len(detections) != 3 || detections[1].Keyword != string(PORCUPINE) || detections[2].FrameIndex != 100 {
+		t.Fatalf("Unexpected recent detections %+v", detections)
+	}
+
+	if err := p.Delete(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if _, err := p.Process(make([]int16, FrameLength())); err == nil {
+		t.Fatalf("Expected Process after Delete to fail.")
+	}
+}
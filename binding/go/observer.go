@@ -0,0 +1,23 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package porcupine
+
+import "time"
+
+// Observer interface
+type Observer interface {
+	// Called for every frame the native library processed, with the time the native call took.
+	OnProcess(latency time.Duration)
+
+	// Called for every reported detection, after detections suppressed by `MinDetectionGap` were filtered out.
+	OnDetection(keywordIndex int, label string)
+}
@@ -0,0 +1,62 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porcupine
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testObserver struct {
+	latencies  []time.Duration
+	detections []string
+}
+
+func (observer *testObserver) OnProcess(latency time.Duration) {
+	observer.latencies = append(observer.latencies, latency)
+}
+
+func (observer *testObserver) OnDetection(keywordIndex int, label string) {
+	observer.detections = append(observer.detections, label)
+}
+
+func TestObserver(t *testing.T) {
+	native := &testNative{version: "1.9.0", processResults: []int{-1, 1, 1, 0}}
+	observer := &testObserver{}
+	p := Porcupine{
+		LibraryPath:     registerTestNative(t, native),
+		BuiltInKeywords: []BuiltInKeyword{ALEXA, PORCUPINE},
+		MinDetectionGap: time.Second,
+	}
+	WithObserver(observer)(&p)
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	for i := 0; i < 4; i++ {
+		if _, err := p.Process(make([]int16, 512)); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+	if _, err := p.Process(make([]int16, 511)); err == nil {
+		t.Fatalf("Expected an error for a frame of the wrong size.")
+	}
+
+	// frames rejected before reaching the library are not observed, and the repeat of "porcupine" is suppressed
+	if len(observer.latencies) != 4 {
+		t.Fatalf("Expected OnProcess for each of 4 frames, but got %d calls", len(observer.latencies))
+	}
+	if !reflect.DeepEqual(observer.detections, []string{"porcupine", "alexa"}) {
+		t.Fatalf("Expected detections [porcupine alexa], but got %v", observer.detections)
+	}
+}
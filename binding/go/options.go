@@ -0,0 +1,139 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package porcupine
+
+import "time"
+
+// Option type
+type Option func(*Porcupine)
+
+// Creates an instance with the given options, validates the configuration and initializes the native engine.
+// The returned instance is ready for `Process` and must be released with `Delete`. This is the preferred way of
+// creating an instance; setting the fields of a `Porcupine` struct and calling `Init` is still supported.
+func NewPorcupine(opts ...Option) (*Porcupine, error) {
+	porcupine := &Porcupine{}
+	for _, opt := range opts {
+		opt(porcupine)
+	}
+	if err := porcupine.Init(); err != nil {
+		return nil, err
+	}
+	return porcupine, nil
+}
+
+// Sets the AccessKey required by Porcupine 2.0 and later.
+func WithAccessKey(accessKey string) Option {
+	return func(porcupine *Porcupine) {
+		porcupine.AccessKey = accessKey
+	}
+}
+
+// Selects built-in keywords to detect. Built-in keywords are detected after any keywords from `WithKeywordPaths`.
+func WithBuiltInKeywords(keywords ...BuiltInKeyword) Option {
+	return func(porcupine *Porcupine) {
+		porcupine.BuiltInKeywords = append(porcupine.BuiltInKeywords, keywords...)
+	}
+}
+
+// Adds keyword files (.ppn) to detect.
+func WithKeywordPaths(keywordPaths ...string) Option {
+	return func(porcupine *Porcupine) {
+		porcupine.KeywordPaths = append(porcupine.KeywordPaths, keywordPaths...)
+	}
+}
+
+// Adds keywords from the contents of keyword files (.ppn), e.g. ones embedded in the application. Detected after
+// keywords from `WithKeywordPaths`.
+func WithKeywordData(keywordData ...[]byte) Option {
+	return func(porcupine *Porcupine) {
+		porcupine.KeywordData = append(porcupine.KeywordData, keywordData...)
+	}
+}
+
+// Selects an ordered set of keywords with their sensitivities. Can't be combined with the other keyword options.
+func WithKeywordSet(set *KeywordSet) Option {
+	return func(porcupine *Porcupine) {
+		porcupine.KeywordSet = set
+	}
+}
+
+// Sets the sensitivity of each keyword, in the order keywords are detected: keyword files first, then keyword
+// data, then built-in keywords. Each value must be within [0, 1]. Defaults to 0.5 for every keyword.
+func WithSensitivities(sensitivities ...float32) Option {
+	return func(porcupine *Porcupine) {
+		porcupine.Sensitivities = append([]float32(nil), sensitivities...)
+	}
+}
+
+// Sets the sensitivities of built-in keywords by name. See `BuiltInSensitivities`.
+func WithBuiltInSensitivities(sensitivities map[BuiltInKeyword]float32) Option {
+	return func(porcupine *Porcupine) {
+		porcupine.BuiltInSensitivities = sensitivities
+	}
+}
+
+// Sets the sensitivities of keyword files by path. See `KeywordPathSensitivities`.
+func WithKeywordPathSensitivities(sensitivities map[string]float32) Option {
+	return func(porcupine *Porcupine) {
+		porcupine.KeywordPathSensitivities = sensitivities
+	}
+}
+
+// Sets the path to the model file. Defaults to the embedded model for the selected language.
+func WithModelPath(modelPath string) Option {
+	return func(porcupine *Porcupine) {
+		porcupine.ModelPath = modelPath
+	}
+}
+
+// Sets the contents of the model file, e.g. a model embedded in the application.
+func WithModelData(modelData []byte) Option {
+	return func(porcupine *Porcupine) {
+		porcupine.ModelData = modelData
+	}
+}
+
+// Selects the language of the model and built-in keywords. The model for the language is extracted from the
+// embedded assets, so the language must be bundled with the build.
+func WithLanguage(language Language) Option {
+	return func(porcupine *Porcupine) {
+		porcupine.Language = language
+	}
+}
+
+// Bounds the time `NewPorcupine` may spend extracting files and creating the native engine. See `InitTimeout`.
+func WithTimeout(timeout time.Duration) Option {
+	return func(porcupine *Porcupine) {
+		porcupine.InitTimeout = timeout
+	}
+}
+
+// Requires a sensitivity for every keyword instead of defaulting to 0.5. See `StrictSensitivities`.
+func WithStrictSensitivities() Option {
+	return func(porcupine *Porcupine) {
+		porcupine.StrictSensitivities = true
+	}
+}
+
+// Suppresses repeated detections of a keyword within `gap` of its previous detection. See `MinDetectionGap`.
+func WithMinDetectionGap(gap time.Duration) Option {
+	return func(porcupine *Porcupine) {
+		porcupine.MinDetectionGap = gap
+	}
+}
+
+// Sets an observer that receives metrics of processed frames and detections. See `Observer`.
+func WithObserver(observer Observer) Option {
+	return func(porcupine *Porcupine) {
+		porcupine.Observer = observer
+	}
+}
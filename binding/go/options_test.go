@@ -0,0 +1,128 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porcupine
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewPorcupine(t *testing.T) {
+	p, err := NewPorcupine(
+		WithModelPath(testModelFile(t)),
+		WithKeywordPaths(testKeywordFile(t, BUMBLEBEE)),
+		WithBuiltInKeywords(PORCUPINE, ALEXA),
+		WithSensitivities(0.3, 0.5, 0.7),
+		WithTimeout(time.Minute))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	expectedLabels := []string{keywordLabelFromPath(testKeywordFile(t, BUMBLEBEE)), string(PORCUPINE), string(ALEXA)}
+	if !reflect.DeepEqual(p.keywordLabels, expectedLabels) {
+		t.Fatalf("Expected keywords %v, but got %v", expectedLabels, p.keywordLabels)
+	}
+	if !reflect.DeepEqual(p.sensitivities, []float32{0.3, 0.5, 0.7}) {
+		t.Fatalf("Expected sensitivities [0.3 0.5 0.7], but got %v", p.sensitivities)
+	}
+
+	if _, err := p.Process(make([]int16, FrameLength())); err != nil {
+		t.Fatalf("Expected instance to be ready for Process, but got: %v", err)
+	}
+
+	p, err = NewPorcupine(WithBuiltInKeywords(PORCUPINE), WithSensitivities(0.5, 0.5))
+	if err == nil || p != nil {
+		t.Fatalf("Expected an error and no instance for an invalid configuration.")
+	}
+}
+
+func TestSensitivitiesByKeyword(t *testing.T) {
+	bumblebeePath := testKeywordFile(t, BUMBLEBEE)
+	p, err := NewPorcupine(
+		WithKeywordPaths(bumblebeePath),
+		WithBuiltInKeywords(PORCUPINE, ALEXA, TERMINATOR),
+		WithKeywordPathSensitivities(map[string]float32{bumblebeePath: 0.3}),
+		WithBuiltInSensitivities(map[BuiltInKeyword]float32{TERMINATOR: 0.9, PORCUPINE: 0}))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	// ALEXA has no entry and falls back to the default
+	expected := []float32{0.3, 0, 0.5, 0.9}
+	if !reflect.DeepEqual(p.sensitivities, expected) {
+		t.Fatalf("Expected sensitivities %v, but got %v", expected, p.sensitivities)
+	}
+
+	invalid := []*Porcupine{
+		{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}, BuiltInSensitivities: map[BuiltInKeyword]float32{ALEXA: 0.5}},
+		{KeywordPaths: []string{bumblebeePath}, KeywordPathSensitivities: map[string]float32{"bumblebee.ppn": 0.5}},
+		{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}, BuiltInSensitivities: map[BuiltInKeyword]float32{PORCUPINE: 2}},
+		{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}, Sensitivities: []float32{0.5},
+			BuiltInSensitivities: map[BuiltInKeyword]float32{PORCUPINE: 0.5}},
+	}
+	for _, invalidPorcupine := range invalid {
+		if err := invalidPorcupine.Init(); err == nil {
+			invalidPorcupine.Delete()
+			t.Fatalf("Expected an error for BuiltInSensitivities %v and KeywordPathSensitivities %v",
+				invalidPorcupine.BuiltInSensitivities, invalidPorcupine.KeywordPathSensitivities)
+		} else {
+			t.Logf("%v", err)
+		}
+	}
+}
+
+func TestStrictSensitivities(t *testing.T) {
+	bumblebeePath := testKeywordFile(t, BUMBLEBEE)
+	tests := []struct {
+		name      string
+		porcupine *Porcupine
+		complete  bool
+	}{
+		{"no sensitivities", &Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}, false},
+		{"missing built-in entry", &Porcupine{
+			BuiltInKeywords:      []BuiltInKeyword{PORCUPINE, ALEXA},
+			BuiltInSensitivities: map[BuiltInKeyword]float32{PORCUPINE: 0.7}}, false},
+		{"missing keyword path entry", &Porcupine{
+			KeywordPaths:         []string{bumblebeePath},
+			BuiltInKeywords:      []BuiltInKeyword{PORCUPINE},
+			BuiltInSensitivities: map[BuiltInKeyword]float32{PORCUPINE: 0.7}}, false},
+		{"sensitivities", &Porcupine{
+			BuiltInKeywords: []BuiltInKeyword{PORCUPINE, ALEXA},
+			Sensitivities:   []float32{0.7, 0.3}}, true},
+		{"complete entries", &Porcupine{
+			KeywordPaths:             []string{bumblebeePath},
+			BuiltInKeywords:          []BuiltInKeyword{PORCUPINE},
+			KeywordPathSensitivities: map[string]float32{bumblebeePath: 0.3},
+			BuiltInSensitivities:     map[BuiltInKeyword]float32{PORCUPINE: 0.7}}, true},
+		{"keyword set", &Porcupine{KeywordSet: NewKeywordSet().AddBuiltIn(PORCUPINE, 0.7)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.porcupine.Validate(); err != nil {
+				t.Fatalf("Expected missing sensitivities to default to 0.5, but got %v", err)
+			}
+
+			WithStrictSensitivities()(tt.porcupine)
+			err := tt.porcupine.Validate()
+			if tt.complete && err != nil {
+				t.Fatalf("Expected no error with every sensitivity given, but got %v", err)
+			}
+			if !tt.complete && (err == nil || !strings.Contains(err.Error(), "StrictSensitivities")) {
+				t.Fatalf("Expected an error naming StrictSensitivities, but got %v", err)
+			}
+		})
+	}
+}
@@ -11,26 +11,32 @@
 
 // Go binding for Porcupine wake word engine. It detects utterances of given keywords within an incoming stream of
 // audio in real-time. It processes incoming audio in consecutive frames and for each frame emits the detection result.
-// The number of samples per frame can be attained by calling `.FrameLength`. The incoming audio needs to have a
-// sample rate equal to `.SampleRate` and be 16-bit linearly-encoded. Porcupine operates on single-channel audio.
+// The number of samples per frame can be attained by calling `FrameLength()`. The incoming audio needs to have a
+// sample rate equal to `SampleRate()` and be 16-bit linearly-encoded. Porcupine operates on single-channel audio.
 
 package porcupine
 
 import (
 	"C"
-	"embed"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"io/ioutil"
-	"log"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unsafe"
 )
 
-//go:embed embedded
-var embeddedFS embed.FS
-
 // PvStatus type
 type PvStatus int
 
@@ -43,6 +49,13 @@ const (
 	STOP_ITERATION   PvStatus = 4
 	KEY_ERROR        PvStatus = 5
 	INVALID_STATE    PvStatus = 6
+
+	// returned by Porcupine 2.0 and later, which require an AccessKey
+	RUNTIME_ERROR            PvStatus = 7
+	ACTIVATION_ERROR         PvStatus = 8
+	ACTIVATION_LIMIT_REACHED PvStatus = 9
+	ACTIVATION_THROTTLED     PvStatus = 10
+	ACTIVATION_REFUSED       PvStatus = 11
 )
 
 func pvStatusToString(status PvStatus) string {
@@ -61,11 +74,51 @@ func pvStatusToString(status PvStatus) string {
 		return "KEY_ERROR"
 	case INVALID_STATE:
 		return "INVALID_STATE"
+	case RUNTIME_ERROR:
+		return "RUNTIME_ERROR"
+	case ACTIVATION_ERROR:
+		return "ACTIVATION_ERROR"
+	case ACTIVATION_LIMIT_REACHED:
+		return "ACTIVATION_LIMIT_REACHED"
+	case ACTIVATION_THROTTLED:
+		return "ACTIVATION_THROTTLED"
+	case ACTIVATION_REFUSED:
+		return "ACTIVATION_REFUSED"
 	default:
 		return fmt.Sprintf("Unknown error code: %d", status)
 	}
 }
 
+// PorcupineError struct
+type PorcupineError struct {
+	// Status reported by the native library, or the status it would report for errors detected by the binding.
+	StatusCode PvStatus
+
+	Message string
+
+	// Additional diagnostics reported by the native library, if any.
+	InnerMessage []string
+}
+
+func (e *PorcupineError) Error() string {
+	message := fmt.Sprintf("%s: %s", pvStatusToString(e.StatusCode), e.Message)
+	for i, inner := range e.InnerMessage {
+		message += fmt.Sprintf("\n  [%d] %s", i, inner)
+	}
+	return message
+}
+
+func newPorcupineError(status PvStatus, format string, args ...interface{}) *PorcupineError {
+	return &PorcupineError{StatusCode: status, Message: fmt.Sprintf(format, args...)}
+}
+
+// Creates an error for a failed native call with the diagnostics reported by the native library attached.
+func newNativeError(native nativePorcupineInterface, status PvStatus, format string, args ...interface{}) *PorcupineError {
+	err := newPorcupineError(status, format, args...)
+	err.InnerMessage = native.nativeErrorStack()
+	return err
+}
+
 // BuiltInKeyword Type
 type BuiltInKeyword string
 
@@ -104,14 +157,58 @@ func (k BuiltInKeyword) IsValid() bool {
 	return false
 }
 
+// Encodes the keyword as a JSON string, e.g. "porcupine".
+func (k BuiltInKeyword) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(k))
+}
+
+// Decodes a keyword from a JSON string. Returns an error for keywords that are neither one of `BuiltInKeywords`,
+// a keyword of an embedded non-English language nor registered with `RegisterKeywordDir`, so that typos in
+// configuration files are caught when they are read.
+func (k *BuiltInKeyword) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return newPorcupineError(INVALID_ARGUMENT, "Built-in keyword must be a string, but got %s", data)
+	}
+	keyword := BuiltInKeyword(name)
+	_, registered := registeredKeywordPath(keyword)
+	if !keyword.IsValid() && !isEmbeddedLanguageKeyword(keyword) && !registered {
+		return newPorcupineError(INVALID_ARGUMENT, "'%s' is not a built-in keyword. Available keywords are: %q",
+			name, BuiltInKeywords)
+	}
+	*k = keyword
+	return nil
+}
+
 // Porcupine struct
 type Porcupine struct {
 	// handle for porcupine instance in C
-	handle uintptr
+	handle unsafe.Pointer
+
+	// serializes Process, ProcessBuffer and Delete, as the native engine is not safe for concurrent use
+	mutex sync.Mutex
+
+	// native library the instance was created with
+	native nativePorcupineInterface
+
+	// frame length of the native library the instance was created with
+	frameLength int
+
+	// AccessKey obtained from Picovoice Console (https://console.picovoice.ai/). Required by Porcupine 2.0 and
+	// later; ignored by earlier versions of the library, such as the one bundled with this package.
+	AccessKey string
 
 	// Absolute path to the file containing model parameters.
 	ModelPath string
 
+	// Contents of a model file, for models that aren't on disk, e.g. ones embedded in the application. Can't be
+	// combined with `ModelPath`.
+	ModelData []byte
+
+	// Language of the model and built-in keywords. Defaults to ENGLISH. The model for the language is extracted
+	// automatically unless `ModelPath` is set.
+	Language Language
+
 	// Sensitivity values for detecting keywords. Each value should be a number within [0, 1]. A
 	// higher sensitivity results in fewer misses at the cost of increasing the false alarm rate.
 	Sensitivities []float32
@@ -119,195 +216,2275 @@ type Porcupine struct {
 	// List of built-in keywords to use.
 	BuiltInKeywords []BuiltInKeyword
 
+	// Sensitivities of built-in keywords by name, as an alternative to `Sensitivities` that doesn't depend on the
+	// order of the keywords. Keywords without an entry use 0.5. Can't be combined with `Sensitivities`.
+	BuiltInSensitivities map[BuiltInKeyword]float32
+
 	// Absolute paths to keyword model files.
 	KeywordPaths []string
+
+	// Sensitivities of keyword files by path, as given in `KeywordPaths`. Keyword files without an entry use 0.5.
+	// Can't be combined with `Sensitivities`.
+	KeywordPathSensitivities map[string]float32
+
+	// Requires a sensitivity to be given for every keyword, with `Sensitivities`, `BuiltInSensitivities`,
+	// `KeywordPathSensitivities` or a `KeywordSet`, so that `Init` returns an error rather than silently using 0.5
+	// for keywords whose sensitivity was left out. Defaults to false.
+	StrictSensitivities bool
+
+	// Contents of keyword model files, for keywords that aren't on disk. Detected after `KeywordPaths` and before
+	// `BuiltInKeywords`, and labeled "keyword_data_<i>".
+	KeywordData [][]byte
+
+	// Ordered set of keywords with their labels and sensitivities. Can't be combined with `BuiltInKeywords`,
+	// `KeywordPaths`, `KeywordData` or `Sensitivities`.
+	KeywordSet *KeywordSet
+
+	// Absolute path to the Porcupine dynamic library. Uses the library bundled with the package if not set.
+	LibraryPath string
+
+	// Policy for NaN or Inf samples passed to `ProcessFloat32`. Defaults to SANITIZE_NON_FINITE.
+	NonFinitePolicy NonFinitePolicy
+
+	// Number of recent detections retained for `RecentDetections()`. Defaults to 16 if not set.
+	DetectionHistorySize int
+
+	// Minimum time between two reported detections of the same keyword. Detections of a keyword within this
+	// window of its previous detection are suppressed and `Process` returns -1 for them. Defaults to 0, which
+	// reports every detection. `Arm` and `ArmAll` end the window early.
+	MinDetectionGap time.Duration
+
+	// Maximum time `Init` may take to extract the embedded files and create the native engine, for services that
+	// prefer to fail fast and retry over blocking on a slow filesystem. If exceeded, `Init` returns an error and
+	// releases the engine once its creation completes in the background. Defaults to 0, which doesn't bound `Init`.
+	InitTimeout time.Duration
+
+	// Receives metrics of processed frames and detections, e.g. to feed Prometheus counters and histograms. The
+	// callbacks run on the goroutine calling `Process` while the instance is locked, so they must return quickly
+	// and must not call methods of the instance. Not set by default.
+	Observer Observer
+
+	// file system and path of the model set by SetModelFS
+	modelFS     fs.FS
+	modelFSPath string
+
+	// configuration resolved by Init, with defaults filled in and built-in keywords appended
+	libraryPath   string
+	modelPath     string
+	keywordPaths  []string
+	sensitivities []float32
+
+	// counters reported by Stats()
+	stats Stats
+
+	// measurements of the last Init reported by InitStats()
+	initStats InitStats
+
+	// labels of the keywords in the order of their detection indices
+	keywordLabels []string
+
+	// number of frames processed since Init
+	frameCount int64
+
+	// output of the native process call; kept on the instance so processing a frame doesn't allocate
+	nativeKeywordIndex int32
+
+	// ring of the most recent detections
+	recentDetections    []Detection
+	recentDetectionsPos int
+
+	// frame of the last reported detection per keyword, or -1 if the keyword is armed
+	lastDetectionFrames []int64
+
+	// keywords whose detections are ignored; set with SetKeywordEnabled
+	disabledKeywords []bool
+
+	// samples passed to ProcessBuffer that don't yet make up a full frame
+	pendingPCM []int16
+
+	// releases the native engine if the instance is garbage collected without Delete
+	guard *engineGuard
+}
+
+// Copy of the native engine of an instance that has a finalizer releasing the engine, as a safety net for instances
+// that are never deleted. The finalizer is attached to this object rather than the instance, since an instance that
+// is a field of another struct can't have a finalizer, and the object holds a copy of the handle, since a reference
+// back to the instance would keep the instance from being collected.
+type engineGuard struct {
+	native nativePorcupineInterface
+	handle unsafe.Pointer
+}
+
+// Points the guard of the instance at its current native engine, creating the guard on first use. Must be called
+// whenever the handle changes.
+func (porcupine *Porcupine) guardEngine() {
+	if porcupine.guard == nil {
+		porcupine.guard = &engineGuard{}
+		runtime.SetFinalizer(porcupine.guard, releaseLeakedEngine)
+	}
+	porcupine.guard.native, porcupine.guard.handle = porcupine.native, porcupine.handle
+}
+
+func releaseLeakedEngine(guard *engineGuard) {
+	if guard.handle == nil {
+		return
+	}
+	logf("warning: releasing the native engine of an instance that was garbage collected without calling Delete")
+	guard.native.nativeDelete(&Porcupine{handle: guard.handle})
+}
+
+// Detection struct
+type Detection struct {
+	// 0 based index of the detected keyword.
+	Index int
+
+	// Label of the detected keyword. Built-in keywords use their name, custom keywords the base name of their file.
+	Keyword string
+
+	// 0 based index of the frame in which the keyword was detected.
+	FrameIndex int64
+
+	// Time from the start of the stream to the end of the frame in which the keyword was detected.
+	Timestamp time.Duration
+
+	// Number of samples from the start of the stream to the end of the frame in which the keyword was detected,
+	// i.e. the sample offset corresponding to `Timestamp`. Useful for cutting the audio around a wake word.
+	SampleOffset int64
+}
+
+// NonFinitePolicy type
+type NonFinitePolicy int
+
+// Possible ways of handling NaN or Inf samples in float input
+const (
+	// Replace NaN with silence and clamp +Inf/-Inf to full scale. Each replaced sample is counted in `Stats()`.
+	SANITIZE_NON_FINITE NonFinitePolicy = 0
+
+	// Reject frames containing NaN or Inf samples with an error.
+	REJECT_NON_FINITE NonFinitePolicy = 1
+)
+
+// Stats struct
+type Stats struct {
+	// Number of NaN or Inf samples that were sanitized while converting float input.
+	NonFiniteSamples uint64
+
+	// Number of detections dropped because the consumer of a detection channel fell behind.
+	DroppedDetections uint64
+}
+
+// InitStats struct
+type InitStats struct {
+	// Wall-clock time the last `Init` took, including extracting files, loading the library and creating the
+	// native engine.
+	Duration time.Duration
+
+	// Part of `Duration` spent in the native library creating the engine, i.e. loading the model and keywords.
+	NativeDuration time.Duration
+
+	// Change of the resident memory of the process while the native engine was created, in bytes. Only measured
+	// on Linux; 0 elsewhere. The native library doesn't report its memory use, so this is an approximation: it
+	// includes allocations of other goroutines, excludes memory the engine reserved but hasn't touched, and can
+	// even be negative. Use it to compare configurations, e.g. numbers of keywords, rather than as an exact size.
+	MemoryDelta int64
 }
 
+// Functions of a loaded native library. Implemented by nativePorcupineType on each platform.
 type nativePorcupineInterface interface {
-	nativeInit(*Porcupine)
-	nativeProcess(*Porcupine, []int)
+	nativeInit(*Porcupine) PvStatus
+	nativeProcess(*Porcupine, []int16) (PvStatus, int)
 	nativeDelete(*Porcupine)
-	nativeSampleRate()
-	nativeFrameLength()
-	nativeVersion()
+	nativeSampleRate() int
+	nativeFrameLength() int
+	nativeVersion() string
+
+	// diagnostics for the last failed call, or nil if the library doesn't provide them
+	nativeErrorStack() []string
 }
-type nativePorcupineType struct{}
+
+const defaultDetectionHistorySize = 16
+
+// Version of Porcupine the embedded model and keyword files were built for. Model and keyword files only work
+// with libraries of the same major and minor version.
+const embeddedAssetsVersion = "1.9.0"
+
+// subdirectory of the extraction directory the embedded files of this build are extracted to
+var embeddedVersionDir = versionDirName(embeddedAssetsVersion, embeddedFS)
+
+// matches the names of the subdirectories of the extraction directory that hold the files of a build
+var versionDirPattern = regexp.MustCompile(`^v[0-9][0-9.]*-[0-9a-f]{12}$`)
+
+// The native library does not report a limit on the number of keywords, so Init enforces the largest number the
+// binding is tested with rather than failing inside the engine.
+const maxKeywords = 1024
 
 // private vars
 var (
-	osName        = getOS()
-	extractionDir = filepath.Join(os.TempDir(), "porcupine")
+	// directory the embedded files are extracted to; read from PORCUPINE_EXTRACTION_DIR when the package is
+	// initialized and changed with SetExtractionDir
+	extractionDir      = defaultExtractionDir()
+	extractionDirMutex sync.Mutex
 
-	defaultModelFile = extractDefaultModel()
-	builtinKeywords  = extractKeywordFiles()
-	libName          = extractLib()
-	nativePorcupine  = nativePorcupineType{}
-)
+	// whether embedded files are extracted when needed; changed with SetAutoExtraction
+	autoExtraction      = true
+	autoExtractionMutex sync.Mutex
 
-var (
-	// Number of audio samples per frame.
-	FrameLength = nativePorcupine.nativeFrameLength()
+	// native libraries loaded so far, keyed by absolute path
+	nativeLibraries      = make(map[string]nativePorcupineInterface)
+	nativeLibrariesMutex sync.Mutex
 
-	// Audio sample rate accepted by Picovoice.
-	SampleRate = nativePorcupine.nativeSampleRate()
+	// flags native libraries are loaded with; changed with SetLibraryLoadFlags
+	libraryLoadFlags      LibraryLoadFlags
+	libraryLoadFlagsMutex sync.Mutex
 
-	// Porcupine version
-	Version = nativePorcupine.nativeVersion()
-)
+	// returns the platform the binding is running on; replaced in tests
+	platformDetector = func() (goos string, goarch string) {
+		return runtime.GOOS, runtime.GOARCH
+	}
 
-// Init function for Porcupine. Must be called before attempting process
-func (porcupine *Porcupine) Init() (err error) {
-	if porcupine.ModelPath == "" {
-		porcupine.ModelPath = defaultModelFile
+	supportedPlatforms = []string{"darwin/amd64", "linux/amd64", "windows/amd64",
+		"linux/arm (Raspberry Pi)", "linux/arm64 (Raspberry Pi 3 and 4)"}
+
+	// returns the contents of /proc/cpuinfo; replaced in tests
+	cpuInfoReader = func() ([]byte, error) {
+		return ioutil.ReadFile("/proc/cpuinfo")
 	}
 
-	if _, err := os.Stat(porcupine.ModelPath); os.IsNotExist(err) {
-		return fmt.Errorf("%s: Specified model file could not be found at %s", pvStatusToString(INVALID_ARGUMENT), porcupine.ModelPath)
+	// model and keyword files already found to exist, so that repeated Inits skip the filesystem
+	existingFiles      = make(map[string]bool)
+	existingFilesMutex sync.Mutex
+
+	// extraction progress reporting; events are kept so that a late callback can be told about earlier ones
+	extractionProgress      func(ExtractionEvent)
+	extractionEvents        []ExtractionEvent
+	extractionProgressMutex sync.Mutex
+
+	// set by loadPorcupine
+	loadMutex        sync.Mutex
+	loaded           bool
+	loadErr          error
+	osName           string
+	defaultModelFile string
+	builtinKeywords  map[string]string
+	libName          string
+
+	// library used by instances that don't set LibraryPath; loaded from defaultLibraryPath if set, otherwise
+	// extracted from the embedded files
+	defaultLibraryPath   = os.Getenv("PORCUPINE_LIBRARY_PATH")
+	nativePorcupine      nativePorcupineInterface
+	nativePorcupineErr   error
+	nativePorcupineMutex sync.Mutex
+)
+
+// UnsupportedPlatformError struct
+type UnsupportedPlatformError struct {
+	OS   string
+	Arch string
+
+	// CPU that was detected on ARM Linux, if any.
+	CPU string
+}
+
+func (e *UnsupportedPlatformError) Error() string {
+	platform := e.OS + "/" + e.Arch
+	if e.CPU != "" {
+		platform += " (" + e.CPU + ")"
 	}
+	return fmt.Sprintf("%s is not a supported platform. Supported platforms are: %s",
+		platform, strings.Join(supportedPlatforms, ", "))
+}
 
-	if porcupine.BuiltInKeywords != nil && len(porcupine.BuiltInKeywords) > 0 {
-		for _, keyword := range porcupine.BuiltInKeywords {
-			if !keyword.IsValid() {
-				return fmt.Errorf("%s: '%s' is not a valid built-in keyword.", pvStatusToString(INVALID_ARGUMENT), keyword)
-			}
-			keywordStr := string(keyword)
-			porcupine.KeywordPaths = append(porcupine.KeywordPaths, builtinKeywords[keywordStr])
-		}
+// MissingSymbolError struct
+type MissingSymbolError struct {
+	// Name of the function the library doesn't export, e.g. "pv_porcupine_init".
+	Name string
+
+	// Path of the library.
+	LibraryPath string
+}
+
+func (e *MissingSymbolError) Error() string {
+	return fmt.Sprintf("Porcupine library at %s does not export %s. The library may be of an incompatible version.",
+		e.LibraryPath, e.Name)
+}
+
+// Extracts the embedded model and keyword files on first use. Returns the same error on every call if
+// extraction failed.
+func loadPorcupine() error {
+	loadMutex.Lock()
+	defer loadMutex.Unlock()
+
+	if loaded {
+		return loadErr
+	}
+	loaded = true
+	if osName, loadErr = getOS(); loadErr != nil {
+		return loadErr
 	}
+	if !embeddedAssets || !autoExtractionEnabled() {
+		return nil
+	}
+	if defaultModelFile, loadErr = extractDefaultModel(); loadErr != nil {
+		return loadErr
+	}
+	builtinKeywords, loadErr = extractKeywordFiles(ENGLISH)
+	return loadErr
+}
 
-	if porcupine.KeywordPaths == nil || len(porcupine.KeywordPaths) == 0 {
-		return fmt.Errorf("%s: No valid keywords were provided.", pvStatusToString(INVALID_ARGUMENT))
+func defaultExtractionDir() string {
+	if dir := os.Getenv("PORCUPINE_EXTRACTION_DIR"); dir != "" {
+		return dir
 	}
+	return filepath.Join(os.TempDir(), "porcupine")
+}
 
-	for _, k := range porcupine.KeywordPaths {
-		if _, err := os.Stat(k); os.IsNotExist(err) {
-			return fmt.Errorf("%s: Keyword file could not be found at %s", pvStatusToString(INVALID_ARGUMENT), k)
+func getExtractionDir() string {
+	extractionDirMutex.Lock()
+	defer extractionDirMutex.Unlock()
+	return extractionDir
+}
+
+// Returns the subdirectory of the extraction directory the embedded files of this build are extracted to, so that
+// applications built with different versions of the package don't overwrite each other's files.
+func getVersionedExtractionDir() string {
+	return filepath.Join(getExtractionDir(), embeddedVersionDir)
+}
+
+// Names the extraction subdirectory of a build after the version of its embedded files and a hash of their names
+// and sizes, e.g. "v1.9.0-3f2a9c81d0e4". Hashing the listing rather than the contents keeps startup fast while
+// still telling apart builds that bundle different files for the same version.
+func versionDirName(version string, fsys fs.FS) string {
+	hash := sha256.New()
+	fs.WalkDir(fsys, ".", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(hash, "%s %d\n", path, info.Size())
+		return nil
+	})
+	return fmt.Sprintf("v%s-%x", version, hash.Sum(nil)[:6])
+}
+
+// Removes the files extracted by builds with other versions of the embedded files from the extraction directory,
+// which are left behind when an application is upgraded. Files written for `ModelData` and `KeywordData` and
+// anything else in the directory are kept. Must not be called while an application built with another version is
+// running from the same extraction directory.
+func PruneExtractionDirs() error {
+	dir := getExtractionDir()
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
 		}
+		return newPorcupineError(IO_ERROR, "Could not read extraction directory '%s': %v", dir, err)
 	}
 
-	if porcupine.Sensitivities == nil {
-		porcupine.Sensitivities = make([]float32, len(porcupine.KeywordPaths))
-		for i := range porcupine.KeywordPaths {
-			porcupine.Sensitivities[i] = 0.5
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == embeddedVersionDir || !versionDirPattern.MatchString(entry.Name()) {
+			continue
 		}
-	} else {
-		for _, s := range porcupine.Sensitivities {
-			if s < 0 || s > 1 {
-				return fmt.Errorf("%s: Sensitivity value of %f is invalid. Must be between [0, 1].",
-					pvStatusToString(INVALID_ARGUMENT), s)
-			}
+		stalePath := filepath.Join(dir, entry.Name())
+		if err := os.RemoveAll(stalePath); err != nil {
+			return newPorcupineError(IO_ERROR, "Could not remove '%s': %v", stalePath, err)
 		}
+		logf("removed files of another version at %s", stalePath)
 	}
+	return nil
+}
 
-	if len(porcupine.KeywordPaths) != len(porcupine.Sensitivities) {
-		return fmt.Errorf("%s: Keyword array size (%d) is not the same size as sensitivities array (%d)",
-			pvStatusToString(INVALID_ARGUMENT), len(porcupine.KeywordPaths), len(porcupine.Sensitivities))
+// Sets the directory the embedded model, keyword files and library are extracted to. Defaults to a `porcupine`
+// directory in `os.TempDir()`, or the PORCUPINE_EXTRACTION_DIR environment variable if it is set. Processes that
+// share a temporary directory, e.g. containers with a shared volume, can use separate directories so that they
+// don't overwrite each other's files. Within the directory, each version of the package extracts its files to its
+// own subdirectory; see `PruneExtractionDirs`.
+//
+// Files that were already extracted are extracted again to the new directory when next used. Instances that are
+// already initialized keep using the old files. Should be called before any instances are initialized.
+func SetExtractionDir(dir string) error {
+	if dir == "" {
+		return newPorcupineError(INVALID_ARGUMENT, "Extraction directory is empty.")
+	}
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return newPorcupineError(IO_ERROR, "Could not create extraction directory '%s': %v", dir, err)
 	}
 
-	ret := nativePorcupine.nativeInit(porcupine)
-	if PvStatus(ret) != SUCCESS {
-		return fmt.Errorf(": Porcupine returned error %s", pvStatusToString(INVALID_ARGUMENT))
+	extractionDirMutex.Lock()
+	extractionDir = dir
+	extractionDirMutex.Unlock()
+
+	forgetExtractedFiles()
+	return nil
+}
+
+// Enables or disables extracting the embedded library, model and keyword files. Extraction is enabled by default.
+// Deployments that provision these files themselves may disable it so that nothing is written to a shared
+// temporary directory, where another user could replace the files with a malicious library before it is loaded.
+// While extraction is disabled, `Init` requires `LibraryPath` (or `SetLibraryPath`), `ModelPath` and
+// `KeywordPaths` to be set and returns an error naming the missing ones. Built-in keywords, `ModelData` and
+// `KeywordData` can't be used, since they are read from files written to the extraction directory.
+func SetAutoExtraction(enabled bool) {
+	autoExtractionMutex.Lock()
+	autoExtraction = enabled
+	autoExtractionMutex.Unlock()
+
+	forgetExtractedFiles()
+}
+
+func autoExtractionEnabled() bool {
+	autoExtractionMutex.Lock()
+	defer autoExtractionMutex.Unlock()
+	return autoExtraction
+}
+
+// Removes the extraction directory with the embedded files extracted to it and any `KeywordData` and `ModelData`
+// written to it. Files are extracted again when next needed. Must only be called once every instance has been
+// released with `Delete`, since the native library is loaded from the extraction directory.
+func CleanupExtractedFiles() error {
+	dir := getExtractionDir()
+	if err := os.RemoveAll(dir); err != nil {
+		return newPorcupineError(IO_ERROR, "Could not remove extraction directory '%s': %v", dir, err)
 	}
 
+	forgetExtractedFiles()
+	CleanCache()
 	return nil
 }
 
-// Releases resources acquired by Porcupine.
-func (porcupine *Porcupine) Delete() error {
-	if porcupine.handle == 0 {
-		return fmt.Errorf("Porcupine has not been initialized or has already been deleted.")
+// Resets the state of extracted files so that they are extracted again on next use.
+func forgetExtractedFiles() {
+	loadMutex.Lock()
+	loaded, loadErr = false, nil
+	loadMutex.Unlock()
+
+	extractedLanguagesMutex.Lock()
+	extractedLanguages = make(map[Language]*languageAssets)
+	extractedLanguagesMutex.Unlock()
+
+	nativePorcupineMutex.Lock()
+	if defaultLibraryPath == "" {
+		nativePorcupine, nativePorcupineErr = nil, nil
+	}
+	nativePorcupineMutex.Unlock()
+}
+
+// Sets the native library used by instances that don't set `LibraryPath`, so that a pre-installed library is
+// used instead of the embedded one, e.g. on systems where the temporary directory is mounted noexec. The library
+// is loaded immediately and an error is returned if it can't be. The embedded library is not extracted once a
+// library path has been set.
+//
+// The package reads the library path from the PORCUPINE_LIBRARY_PATH environment variable when it is
+// initialized, which also skips extracting the embedded library at startup.
+func SetLibraryPath(libPath string) error {
+	library, err := getNativeLibrary(libPath)
+	if err != nil {
+		return err
 	}
 
-	nativePorcupine.nativeDelete(porcupine)
+	nativePorcupineMutex.Lock()
+	defer nativePorcupineMutex.Unlock()
+
+	defaultLibraryPath = libPath
+	nativePorcupine, nativePorcupineErr = library, nil
 	return nil
 }
 
-// Processes a frame of the incoming audio stream and emits the detection result.
-// Frame of audio The number of samples per frame can be attained by calling
-// `.FrameLength`. The incoming audio needs to have a sample rate equal to `.Sample` and be 16-bit
-// linearly-encoded. Porcupine operates on single-channel audio.
-// Returns a 0 based index if keyword was detected in frame. Returns -1 if no detection was made.
-func (porcupine *Porcupine) Process(pcm []int16) (keywordIndex int, err error) {
+// LibraryLoadFlags type
+type LibraryLoadFlags int
+
+// Flags controlling how the native library is loaded on Linux, macOS and Raspberry Pi, where they map to the
+// `dlopen` mode. They can be combined, e.g. `LOAD_LAZY | LOAD_GLOBAL`. Windows loads libraries with `LoadLibrary`,
+// which has no equivalent, so they are ignored there.
+const (
+	// Resolves all undefined symbols of the library when it is loaded (RTLD_NOW), so that a library with
+	// unresolved symbols fails to load rather than failing when they are first used. The default.
+	LOAD_NOW LibraryLoadFlags = 0
+
+	// Resolves function symbols when they are first called (RTLD_LAZY), so that a library with unresolved symbols
+	// that are never used, e.g. ones of an unused optional dependency, can still be loaded.
+	LOAD_LAZY LibraryLoadFlags = 1 << 0
+
+	// Makes the symbols of the library available to libraries loaded after it (RTLD_GLOBAL), e.g. plugins or
+	// other Picovoice engines that depend on them. Without it, symbols are local to the library on Linux, while
+	// macOS makes them global by default.
+	LOAD_GLOBAL LibraryLoadFlags = 1 << 1
+)
+
+// Sets the flags native libraries are loaded with. Only affects libraries loaded afterwards, so it should be called
+// before any instances are initialized or `SetLibraryPath` or `Preload` are called; libraries that were already
+// loaded keep their flags. Defaults to LOAD_NOW.
+func SetLibraryLoadFlags(flags LibraryLoadFlags) {
+	libraryLoadFlagsMutex.Lock()
+	defer libraryLoadFlagsMutex.Unlock()
+	libraryLoadFlags = flags
+}
+
+func getLibraryLoadFlags() LibraryLoadFlags {
+	libraryLoadFlagsMutex.Lock()
+	defer libraryLoadFlagsMutex.Unlock()
+	return libraryLoadFlags
+}
+
+// Returns the native library used by instances that don't set `LibraryPath`, loading it on first use.
+func getDefaultLibrary() (nativePorcupineInterface, error) {
+	nativePorcupineMutex.Lock()
+	defer nativePorcupineMutex.Unlock()
 
-	if porcupine.handle == 0 {
-		return -1, fmt.Errorf("Porcupine has not been initialized or has been deleted.")
+	if nativePorcupine == nil && nativePorcupineErr == nil {
+		if defaultLibraryPath != "" {
+			nativePorcupine, nativePorcupineErr = getNativeLibrary(defaultLibraryPath)
+		} else if !autoExtractionEnabled() {
+			nativePorcupineErr = newPorcupineError(INVALID_ARGUMENT, "Automatic extraction is disabled. Set "+
+				"LibraryPath, SetLibraryPath or the PORCUPINE_LIBRARY_PATH environment variable.")
+		} else if nativePorcupineErr = loadPorcupine(); nativePorcupineErr == nil {
+			nativePorcupine, nativePorcupineErr = loadDefaultLibrary()
+		}
 	}
+	return nativePorcupine, nativePorcupineErr
+}
 
-	if len(pcm) != FrameLength {
-		return -1, fmt.Errorf("Input data frame size (%d) does not match required size of %d", len(pcm), FrameLength)
+// Returns the path of the native library used by instances that don't set `LibraryPath`, or "" if it hasn't been
+// loaded.
+func getDefaultLibraryPath() string {
+	nativePorcupineMutex.Lock()
+	defer nativePorcupineMutex.Unlock()
+
+	if nativePorcupine == nil {
+		return ""
 	}
+	if defaultLibraryPath != "" {
+		return defaultLibraryPath
+	}
+	return libName
+}
 
-	// call process
-	ret, index := nativePorcupine.nativeProcess(porcupine, pcm)
-	if PvStatus(ret) != SUCCESS {
-		return -1, fmt.Errorf("Process audio frame failed with PvStatus: %d", ret)
+// Porcupine 2.0 and later take an AccessKey as the first argument of `pv_porcupine_init`.
+func requiresAccessKey(version string) bool {
+	major, err := strconv.Atoi(strings.SplitN(version, ".", 2)[0])
+	return err == nil && major >= 2
+}
+
+// Reports whether a library of version `libraryVersion` can load model and keyword files built for
+// `assetsVersion`, i.e. whether their major and minor versions match.
+func versionsCompatible(libraryVersion string, assetsVersion string) bool {
+	majorMinor := func(version string) string {
+		parts := strings.SplitN(version, ".", 3)
+		if len(parts) < 2 {
+			return version
+		}
+		return parts[0] + "." + parts[1]
 	}
+	return majorMinor(libraryVersion) == majorMinor(assetsVersion)
+}
 
-	return index, nil
+// Reports whether a model or keyword file exists. Files that were found are remembered until `CleanCache`.
+func fileExists(path string) bool {
+	existingFilesMutex.Lock()
+	defer existingFilesMutex.Unlock()
+
+	if existingFiles[path] {
+		return true
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return false
+	}
+	existingFiles[path] = true
+	return true
 }
 
-func getOS() string {
-	switch os := runtime.GOOS; os {
-	case "darwin":
-		return "mac"
-	case "linux":
-		return "linux"
-	case "windows":
-		return "windows"
-	default:
-		log.Fatalf("%s is not a supported OS", os)
+// Forgets which model and keyword files were found to exist. `Init` checks that the files it is given exist and
+// skips the check for files it has already found, which speeds up creating many engines. Call this after
+// removing or replacing files on disk so that they are checked again.
+func CleanCache() {
+	existingFilesMutex.Lock()
+	defer existingFilesMutex.Unlock()
+
+	existingFiles = make(map[string]bool)
+}
+
+// Returns the native library at `libPath`, loading it on first use. Each library file gets its own set of
+// symbols, so engines created from different library files don't interfere with each other.
+func getNativeLibrary(libPath string) (nativePorcupineInterface, error) {
+	absPath, err := filepath.Abs(libPath)
+	if err != nil {
+		return nil, err
+	}
+
+	nativeLibrariesMutex.Lock()
+	defer nativeLibrariesMutex.Unlock()
+
+	if library, ok := nativeLibraries[absPath]; ok {
+		return library, nil
+	}
+
+	if _, err := os.Stat(absPath); os.IsNotExist(err) {
+		return nil, newPorcupineError(INVALID_ARGUMENT, "Library file could not be found at %s", absPath)
+	}
+
+	library, err := loadNativeLibrary(absPath)
+	if err != nil {
+		logf("failed to load library %s: %v", absPath, err)
+		return nil, err
+	}
+	nativeLibraries[absPath] = library
+	logf("loaded library %s (version %s)", absPath, library.nativeVersion())
+	return library, nil
+}
+
+// Returns the number of audio samples per frame accepted by the default native library, loading the library on
+// first use. Returns 0 if the library could not be loaded; `LibraryError` returns the reason.
+func FrameLength() int {
+	library, err := getDefaultLibrary()
+	if err != nil {
+		return 0
+	}
+	return library.nativeFrameLength()
+}
+
+// Returns the audio sample rate accepted by the default native library, loading the library on first use.
+// Returns 0 if the library could not be loaded; `LibraryError` returns the reason.
+func SampleRate() int {
+	library, err := getDefaultLibrary()
+	if err != nil {
+		return 0
+	}
+	return library.nativeSampleRate()
+}
+
+// Returns the version of the default native library, loading the library on first use. Returns "" if the
+// library could not be loaded; `LibraryError` returns the reason.
+func Version() string {
+	library, err := getDefaultLibrary()
+	if err != nil {
 		return ""
 	}
+	return library.nativeVersion()
 }
 
-func extractDefaultModel() string {
-	modelPath := "embedded/lib/common/porcupine_params.pv"
-	return extractFile(modelPath, extractionDir)
+// Returns the error that prevented the default native library from loading, or nil if it loaded. Loads the
+// library if it hasn't been loaded yet.
+func LibraryError() error {
+	_, err := getDefaultLibrary()
+	return err
 }
 
-func extractKeywordFiles() map[string]string {
-	keywordDirPath := "embedded/resources/keyword_files/" + osName
-	keywordFiles, err := embeddedFS.ReadDir(keywordDirPath)
+// Reports whether the engine is usable on the current platform, so that applications can choose a fallback before
+// creating any instance. Loads the default native library if it hasn't been loaded yet and returns false with the
+// reason if the platform is unsupported, the library could not be loaded or it doesn't export the required
+// symbols, e.g. an `UnsupportedPlatformError` or `MissingSymbolError`.
+func IsAvailable() (bool, error) {
+	if err := LibraryError(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Extracts the embedded model and English keyword files and loads the default native library now rather than in
+// the first `Init`, so that the cost can be paid and timed deliberately, e.g. during a startup health check.
+// Returns the error that would otherwise surface from `Init`. Calling Preload again does nothing unless the
+// extraction directory was changed or cleaned up in between.
+func Preload() error {
+	if err := loadPorcupine(); err != nil {
+		return err
+	}
+	_, err := getDefaultLibrary()
+	return err
+}
+
+// Init function for Porcupine. Must be called before attempting process. Returns an error if the instance is
+// already initialized; call `Delete` first to initialize it again, e.g. after changing its configuration.
+func (porcupine *Porcupine) Init() (err error) {
+	porcupine.mutex.Lock()
+	defer porcupine.mutex.Unlock()
+
+	if porcupine.handle != nil {
+		return newPorcupineError(INVALID_STATE, "Porcupine is already initialized. Call Delete before calling Init again.")
+	}
+
+	if porcupine.InitTimeout > 0 {
+		return porcupine.initWithTimeout(porcupine.InitTimeout)
+	}
+
+	start := time.Now()
+	config, err := porcupine.resolveConfig()
 	if err != nil {
-		log.Fatalf("%v", err)
+		return err
 	}
+	if err := porcupine.initEngine(config); err != nil {
+		return err
+	}
+	porcupine.initStats.Duration = time.Since(start)
+	porcupine.finishInit(config)
+	return nil
+}
 
-	extractedKeywords := make(map[string]string)
-	for _, keywordFile := range keywordFiles {
-		keywordPath := keywordDirPath + "/" + keywordFile.Name()
-		keywordName := strings.Split(keywordFile.Name(), "_")[0]
-		extractedKeywords[keywordName] = extractFile(keywordPath, extractionDir)
+// Calls `Init` up to `attempts` times for environments where it can fail transiently, e.g. while validating the
+// AccessKey with a flaky network. Only errors that may succeed on another attempt are retried: IO_ERROR,
+// OUT_OF_MEMORY, RUNTIME_ERROR (which includes exceeding `InitTimeout`), ACTIVATION_ERROR and ACTIVATION_THROTTLED.
+// Other errors, e.g. INVALID_ARGUMENT for a bad configuration or ACTIVATION_REFUSED for a bad AccessKey, are
+// returned immediately. Waits `backoff` before the first retry and doubles the wait for each further retry. Returns
+// the error of the last attempt.
+func (porcupine *Porcupine) InitWithRetry(attempts int, backoff time.Duration) error {
+	if attempts < 1 {
+		return newPorcupineError(INVALID_ARGUMENT, "Number of attempts must be at least 1, but got %d.", attempts)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := porcupine.Init()
+		if err == nil || attempt == attempts || !isTransientError(err) {
+			return err
+		}
+		logf("init attempt %d of %d failed, retrying in %v: %v", attempt, attempts, backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
 	}
-	return extractedKeywords
 }
 
-func extractLib() string {
-	var libPath string
-	switch os := runtime.GOOS; os {
-	case "darwin":
-		libPath = fmt.Sprintf("embedded/lib/%s/x86_64/libpv_porcupine.dylib", osName)
-	case "linux":
-		libPath = fmt.Sprintf("embedded/lib/%s/x86_64/libpv_porcupine.so", osName)
-	case "windows":
-		libPath = fmt.Sprintf("embedded/lib/%s/amd64/libpv_porcupine.dll", osName)
-	default:
-		log.Fatalf("%s is not a supported OS", os)
+// Reports whether an error of `Init` may not recur on another attempt.
+func isTransientError(err error) bool {
+	var porcupineErr *PorcupineError
+	if !errors.As(err, &porcupineErr) {
+		return false
+	}
+	switch porcupineErr.StatusCode {
+	case IO_ERROR, OUT_OF_MEMORY, RUNTIME_ERROR, ACTIVATION_ERROR, ACTIVATION_THROTTLED:
+		return true
+	}
+	return false
+}
+
+// Runs the extraction and native init of `Init` on a copy of the configuration in a goroutine, so that `Init` can
+// return once `timeout` has passed. An abandoned init can't be interrupted; it runs to completion and releases the
+// engine it created. Files are extracted atomically, so an abandoned extraction never leaves partial files behind.
+func (porcupine *Porcupine) initWithTimeout(timeout time.Duration) error {
+	start := time.Now()
+	pending := &Porcupine{
+		AccessKey:       porcupine.AccessKey,
+		ModelPath:       porcupine.ModelPath,
+		ModelData:       porcupine.ModelData,
+		Language:        porcupine.Language,
+		Sensitivities:   append([]float32(nil), porcupine.Sensitivities...),
+		BuiltInKeywords: append([]BuiltInKeyword(nil), porcupine.BuiltInKeywords...),
+		KeywordPaths:    append([]string(nil), porcupine.KeywordPaths...),
+		KeywordData:     append([][]byte(nil), porcupine.KeywordData...),
+		KeywordSet:      porcupine.KeywordSet,
+		LibraryPath:     porcupine.LibraryPath,
+
+		BuiltInSensitivities:     porcupine.BuiltInSensitivities,
+		KeywordPathSensitivities: porcupine.KeywordPathSensitivities,
+		StrictSensitivities:      porcupine.StrictSensitivities,
+
+		modelFS:     porcupine.modelFS,
+		modelFSPath: porcupine.modelFSPath,
+	}
+
+	type initResult struct {
+		config *resolvedConfig
+		err    error
 	}
+	done := make(chan initResult)
+	abandoned := make(chan struct{})
+	go func() {
+		config, err := pending.resolveConfig()
+		if err == nil {
+			err = pending.initEngine(config)
+		}
+		select {
+		case done <- initResult{config, err}:
+		case <-abandoned:
+			if err == nil {
+				pending.native.nativeDelete(pending)
+				logf("released engine of an init that exceeded its timeout")
+			}
+		}
+	}()
 
-	return extractFile(libPath, extractionDir)
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case result := <-done:
+		if result.err != nil {
+			return result.err
+		}
+		porcupine.handle = pending.handle
+		porcupine.modelPath = pending.modelPath
+		porcupine.keywordPaths = pending.keywordPaths
+		porcupine.sensitivities = pending.sensitivities
+		porcupine.native = pending.native
+		porcupine.frameLength = pending.frameLength
+		porcupine.initStats = pending.initStats
+		porcupine.initStats.Duration = time.Since(start)
+		porcupine.finishInit(result.config)
+		return nil
+	case <-timer.C:
+		close(abandoned)
+		return newPorcupineError(RUNTIME_ERROR, "Porcupine init did not complete within %v", timeout)
+	}
 }
 
-func extractFile(srcFile string, dstDir string) string {
-	bytes, readErr := embeddedFS.ReadFile(srcFile)
-	if readErr != nil {
-		log.Fatalf("%v", readErr)
+// Creates the native engine for a resolved configuration.
+func (porcupine *Porcupine) initEngine(config *resolvedConfig) error {
+	porcupine.modelPath = config.modelPath
+	porcupine.keywordPaths = config.keywordPaths
+	porcupine.sensitivities = config.sensitivities
+	porcupine.native = config.native
+	porcupine.frameLength = config.native.nativeFrameLength()
+
+	start, memoryBefore := time.Now(), residentMemory()
+	ret := porcupine.native.nativeInit(porcupine)
+	if PvStatus(ret) != SUCCESS {
+		return newNativeError(porcupine.native, ret, "Porcupine init failed")
 	}
+	porcupine.initStats = InitStats{NativeDuration: time.Since(start), MemoryDelta: residentMemory() - memoryBefore}
+	logf("initialized engine with model %s and keywords %v", config.modelPath, config.keywordLabels)
+	return nil
+}
 
-	extractedFilepath := filepath.Join(dstDir, srcFile)
-	os.MkdirAll(filepath.Dir(extractedFilepath), 0777)
-	writeErr := ioutil.WriteFile(extractedFilepath, bytes, 0777)
-	if writeErr != nil {
-		log.Fatalf("%v", writeErr)
+// Sets up the detection state once the native engine has been created.
+func (porcupine *Porcupine) finishInit(config *resolvedConfig) {
+	porcupine.libraryPath = config.libraryPath
+	porcupine.keywordLabels = config.keywordLabels
+	porcupine.lastDetectionFrames = make([]int64, len(config.keywordPaths))
+	porcupine.disabledKeywords = make([]bool, len(config.keywordPaths))
+	porcupine.pendingPCM = make([]int16, 0, porcupine.frameLength)
+	porcupine.resetDetectionState()
+	porcupine.guardEngine()
+}
+
+// Clears the detection state so that processing starts afresh, e.g. when switching to a different audio source,
+// while keeping the configuration. The native library has no way of resetting an engine, so Reset releases the
+// native engine and creates a new one. This skips extracting and validating files but still reloads the model and
+// keywords, so it takes about as long as the native part of `Init` and shouldn't be called between frames of a
+// live stream.
+func (porcupine *Porcupine) Reset() error {
+	porcupine.mutex.Lock()
+	defer porcupine.mutex.Unlock()
+
+	if porcupine.handle == nil {
+		return newPorcupineError(INVALID_STATE, "Porcupine has not been initialized or has been deleted.")
+	}
+
+	porcupine.native.nativeDelete(porcupine)
+	porcupine.handle = nil
+	ret := porcupine.native.nativeInit(porcupine)
+	if PvStatus(ret) != SUCCESS {
+		porcupine.handle = nil
+		porcupine.guardEngine()
+		return newNativeError(porcupine.native, ret, "Porcupine init failed")
+	}
+
+	porcupine.resetDetectionState()
+	porcupine.guardEngine()
+	return nil
+}
+
+// Creates a separate instance with the same configuration and its own native engine, e.g. one per worker
+// goroutine. The model, keywords and current sensitivities resolved by `Init` are reused, so no files are extracted
+// or validated again, and the settings of the instance, such as `MinDetectionGap` and `Observer`, are copied. The
+// clone starts with fresh detection state. Each instance must be released with its own call to `Delete`.
+func (porcupine *Porcupine) Clone() (*Porcupine, error) {
+	porcupine.mutex.Lock()
+	defer porcupine.mutex.Unlock()
+
+	if porcupine.handle == nil {
+		return nil, newPorcupineError(INVALID_STATE, "Porcupine has not been initialized or has been deleted.")
+	}
+
+	clone := &Porcupine{
+		AccessKey:                porcupine.AccessKey,
+		ModelPath:                porcupine.ModelPath,
+		ModelData:                porcupine.ModelData,
+		Language:                 porcupine.Language,
+		Sensitivities:            append([]float32(nil), porcupine.Sensitivities...),
+		BuiltInKeywords:          append([]BuiltInKeyword(nil), porcupine.BuiltInKeywords...),
+		BuiltInSensitivities:     porcupine.BuiltInSensitivities,
+		KeywordPaths:             append([]string(nil), porcupine.KeywordPaths...),
+		KeywordPathSensitivities: porcupine.KeywordPathSensitivities,
+		StrictSensitivities:      porcupine.StrictSensitivities,
+		KeywordData:              append([][]byte(nil), porcupine.KeywordData...),
+		KeywordSet:               porcupine.KeywordSet,
+		LibraryPath:              porcupine.LibraryPath,
+		NonFinitePolicy:          porcupine.NonFinitePolicy,
+		DetectionHistorySize:     porcupine.DetectionHistorySize,
+		MinDetectionGap:          porcupine.MinDetectionGap,
+		InitTimeout:              porcupine.InitTimeout,
+		Observer:                 porcupine.Observer,
+
+		modelFS:     porcupine.modelFS,
+		modelFSPath: porcupine.modelFSPath,
+	}
+	config := &resolvedConfig{
+		native:        porcupine.native,
+		libraryPath:   porcupine.libraryPath,
+		modelPath:     porcupine.modelPath,
+		keywordPaths:  append([]string(nil), porcupine.keywordPaths...),
+		keywordLabels: append([]string(nil), porcupine.keywordLabels...),
+		sensitivities: append([]float32(nil), porcupine.sensitivities...),
+	}
+	start := time.Now()
+	if err := clone.initEngine(config); err != nil {
+		return nil, err
+	}
+	clone.initStats.Duration = time.Since(start)
+	clone.finishInit(config)
+	copy(clone.disabledKeywords, porcupine.disabledKeywords)
+	return clone, nil
+}
+
+// Changes the sensitivity of each keyword, in the same order as `Sensitivities`, e.g. to adapt to ambient noise.
+// The native library can't change the sensitivities of an engine, so a new native engine is created with the
+// same model and keywords and replaces the current one. The frame counter, detection history and cooldowns are
+// kept. If creating the new engine fails, the current engine and sensitivities stay in use. The configuration is
+// updated so that a later `Init` or `SaveState` uses the new sensitivities: a `KeywordSet` is replaced by a copy
+// with the new sensitivities, and an instance configured with `BuiltInSensitivities` or `KeywordPathSensitivities`
+// is switched to `Sensitivities`.
+func (porcupine *Porcupine) SetSensitivities(sensitivities []float32) error {
+	porcupine.mutex.Lock()
+	defer porcupine.mutex.Unlock()
+
+	if porcupine.handle == nil {
+		return newPorcupineError(INVALID_STATE, "Porcupine has not been initialized or has been deleted.")
+	}
+	if len(sensitivities) != len(porcupine.keywordPaths) {
+		return newPorcupineError(INVALID_ARGUMENT, "Keyword array size (%d) is not the same size as sensitivities array (%d)",
+			len(porcupine.keywordPaths), len(sensitivities))
+	}
+	for i, s := range sensitivities {
+		if !(s >= 0 && s <= 1) {
+			return newPorcupineError(INVALID_ARGUMENT, "Sensitivity value of %g at index %d is invalid. "+
+				"Must be between [0, 1].", s, i)
+		}
+	}
+
+	oldHandle, oldSensitivities := porcupine.handle, porcupine.sensitivities
+	porcupine.sensitivities = append([]float32(nil), sensitivities...)
+	ret := porcupine.native.nativeInit(porcupine)
+	if PvStatus(ret) != SUCCESS {
+		porcupine.handle, porcupine.sensitivities = oldHandle, oldSensitivities
+		return newNativeError(porcupine.native, ret, "Porcupine init failed")
+	}
+
+	newHandle := porcupine.handle
+	porcupine.handle = oldHandle
+	porcupine.native.nativeDelete(porcupine)
+	porcupine.handle = newHandle
+	porcupine.guardEngine()
+
+	if porcupine.KeywordSet != nil {
+		porcupine.KeywordSet = porcupine.KeywordSet.withSensitivities(sensitivities)
+		return nil
+	}
+	porcupine.BuiltInSensitivities = nil
+	porcupine.KeywordPathSensitivities = nil
+	porcupine.Sensitivities = append([]float32(nil), sensitivities...)
+	return nil
+}
+
+// Clears the frame counter, detection history, cooldowns and partial frames.
+func (porcupine *Porcupine) resetDetectionState() {
+	porcupine.frameCount = 0
+	porcupine.recentDetections = nil
+	porcupine.recentDetectionsPos = 0
+	porcupine.pendingPCM = porcupine.pendingPCM[:0]
+	porcupine.armAll()
+}
+
+// Returns the maximum number of keywords a single engine can detect.
+func MaxKeywords() int {
+	return maxKeywords
+}
+
+// Checks the configuration for the errors `Init` would report before creating the native engine, without
+// creating it. Useful for giving feedback on a configuration before committing to `Init`.
+func (porcupine *Porcupine) Validate() error {
+	_, err := porcupine.resolveConfig()
+	return err
+}
+
+// platforms keyword files are built for, which they are named after, e.g. `porcupine_linux.ppn`
+var keywordFilePlatforms = []string{"android", "beaglebone", "cortexm", "ios", "jetson", "linux", "mac",
+	"raspberry-pi", "wasm", "windows"}
+
+// Checks that a keyword file can be used with a model on this platform before it is used in `Init`, e.g. to validate
+// a keyword file a user selected in a UI. Creates an engine with just the keyword and returns an error that names
+// the problem: the file is missing, named for another platform, or could not be loaded by the native library, which
+// happens for files that are corrupt or built for another language than the model. Uses the default model if
+// `modelPath` is empty. Options such as `WithAccessKey` or `WithLanguage` configure the engine.
+func ValidateKeywordFile(modelPath, keywordPath string, opts ...Option) error {
+	info, err := os.Stat(keywordPath)
+	if err != nil {
+		return newPorcupineError(INVALID_ARGUMENT, "Keyword file could not be found at %s", keywordPath)
+	}
+	if !info.Mode().IsRegular() {
+		return newPorcupineError(INVALID_ARGUMENT, "Keyword file '%s' is not a regular file.", keywordPath)
+	}
+	if filepath.Ext(keywordPath) != ".ppn" {
+		return newPorcupineError(INVALID_ARGUMENT, "Keyword file '%s' is not a .ppn file.", keywordPath)
+	}
+	if platform, err := getOS(); err == nil {
+		name := strings.TrimSuffix(filepath.Base(keywordPath), ".ppn")
+		for _, filePlatform := range keywordFilePlatforms {
+			if filePlatform != platform && strings.HasSuffix(name, "_"+filePlatform) {
+				return newPorcupineError(INVALID_ARGUMENT, "Keyword file '%s' is built for %s, but this platform "+
+					"requires a keyword file built for %s.", keywordPath, filePlatform, platform)
+			}
+		}
+	}
+
+	porcupine := &Porcupine{}
+	for _, opt := range opts {
+		opt(porcupine)
+	}
+	porcupine.ModelPath = modelPath
+	porcupine.KeywordPaths = []string{keywordPath}
+	porcupine.BuiltInKeywords = nil
+	porcupine.KeywordData = nil
+	porcupine.KeywordSet = nil
+
+	config, err := porcupine.resolveConfig()
+	if err != nil {
+		return err
+	}
+	if err := porcupine.initEngine(config); err != nil {
+		validationErr := newPorcupineError(INVALID_ARGUMENT, "Keyword file '%s' could not be loaded with model "+
+			"'%s'. The file may be corrupt or built for another language than the model.", keywordPath, config.modelPath)
+		var porcupineErr *PorcupineError
+		if errors.As(err, &porcupineErr) {
+			validationErr.StatusCode = porcupineErr.StatusCode
+			validationErr.InnerMessage = porcupineErr.InnerMessage
+		}
+		return validationErr
+	}
+	return porcupine.Delete()
+}
+
+// configuration with defaults filled in and built-in keywords resolved to their files
+type resolvedConfig struct {
+	native        nativePorcupineInterface
+	libraryPath   string
+	modelPath     string
+	keywordPaths  []string
+	keywordLabels []string
+	sensitivities []float32
+}
+
+func (porcupine *Porcupine) resolveConfig() (*resolvedConfig, error) {
+	extract := autoExtractionEnabled()
+	if !extract {
+		if err := porcupine.checkProvisionedFiles(); err != nil {
+			return nil, err
+		}
+	}
+	if err := loadPorcupine(); err != nil {
+		return nil, err
+	}
+
+	var native nativePorcupineInterface
+	var libraryPath string
+	var err error
+	if porcupine.LibraryPath != "" {
+		native, err = getNativeLibrary(porcupine.LibraryPath)
+		libraryPath, _ = filepath.Abs(porcupine.LibraryPath)
+	} else {
+		native, err = getDefaultLibrary()
+		libraryPath = getDefaultLibraryPath()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if requiresAccessKey(native.nativeVersion()) && porcupine.AccessKey == "" {
+		return nil, newPorcupineError(INVALID_ARGUMENT, "AccessKey is required by Porcupine %s. Get one from Picovoice Console "+
+			"(https://console.picovoice.ai/).", native.nativeVersion())
+	}
+
+	assets := &languageAssets{language: porcupine.Language}
+	if extract && porcupine.needsLanguageAssets() {
+		if assets, err = getLanguageAssets(porcupine.Language); err != nil {
+			return nil, err
+		}
+	}
+
+	modelPath := porcupine.ModelPath
+	if porcupine.ModelData != nil {
+		if modelPath != "" {
+			return nil, newPorcupineError(INVALID_ARGUMENT, "Only one of ModelPath and ModelData can be set.")
+		}
+		if modelPath, err = writeDataFile(porcupine.ModelData, ".pv"); err != nil {
+			return nil, err
+		}
+	}
+	if porcupine.modelFS != nil {
+		if modelPath != "" {
+			return nil, newPorcupineError(INVALID_ARGUMENT, "SetModelFS can't be combined with ModelPath or ModelData.")
+		}
+		if modelPath, err = writeFSFile(porcupine.modelFS, porcupine.modelFSPath, ".pv"); err != nil {
+			return nil, err
+		}
+	}
+	if modelPath == "" {
+		modelPath = assets.modelPath
+	}
+	if modelPath == "" && !embeddedAssets {
+		return nil, newPorcupineError(INVALID_ARGUMENT, "ModelPath must be set in builds with the porcupine_noembed tag.")
+	}
+
+	if !fileExists(modelPath) {
+		return nil, newPorcupineError(INVALID_ARGUMENT, "Specified model file could not be found at %s", modelPath)
+	}
+
+	keywordPaths := make([]string, 0, len(porcupine.KeywordPaths)+len(porcupine.BuiltInKeywords))
+	keywordLabels := make([]string, 0, len(porcupine.KeywordPaths)+len(porcupine.BuiltInKeywords))
+	for _, k := range porcupine.KeywordPaths {
+		keywordPath := k
+		if porcupine.modelFS != nil && fs.ValidPath(k) {
+			if keywordPath, err = writeFSFile(porcupine.modelFS, k, ".ppn"); err != nil {
+				return nil, err
+			}
+		}
+		keywordPaths = append(keywordPaths, keywordPath)
+		keywordLabels = append(keywordLabels, keywordLabelFromPath(k))
+	}
+	for i, data := range porcupine.KeywordData {
+		keywordPath, err := writeDataFile(data, ".ppn")
+		if err != nil {
+			return nil, err
+		}
+		keywordPaths = append(keywordPaths, keywordPath)
+		keywordLabels = append(keywordLabels, fmt.Sprintf("keyword_data_%d", i))
+	}
+
+	if porcupine.BuiltInKeywords != nil && len(porcupine.BuiltInKeywords) > 0 {
+		for _, keyword := range porcupine.BuiltInKeywords {
+			keywordPath, err := assets.builtInKeywordPath(keyword)
+			if err != nil {
+				return nil, err
+			}
+			keywordPaths = append(keywordPaths, keywordPath)
+			keywordLabels = append(keywordLabels, string(keyword))
+		}
+	}
+
+	sensitivities := porcupine.Sensitivities
+	if porcupine.BuiltInSensitivities != nil || porcupine.KeywordPathSensitivities != nil {
+		if sensitivities != nil {
+			return nil, newPorcupineError(INVALID_ARGUMENT, "Sensitivities can't be combined with "+
+				"BuiltInSensitivities or KeywordPathSensitivities.")
+		}
+		if sensitivities, err = porcupine.sensitivitiesByKeyword(); err != nil {
+			return nil, err
+		}
+	}
+
+	if porcupine.KeywordSet != nil {
+		if len(keywordPaths) > 0 || sensitivities != nil {
+			return nil, newPorcupineError(INVALID_ARGUMENT, "KeywordSet can't be combined with BuiltInKeywords, "+
+				"KeywordPaths, KeywordData, Sensitivities, BuiltInSensitivities or KeywordPathSensitivities.")
+		}
+		if keywordPaths, keywordLabels, sensitivities, err = porcupine.KeywordSet.resolve(assets); err != nil {
+			return nil, err
+		}
+	}
+
+	if assets.contains(modelPath, keywordPaths) && !versionsCompatible(native.nativeVersion(), embeddedAssetsVersion) {
+		return nil, newPorcupineError(INVALID_ARGUMENT, "Library version %s does not match expected version %s of "+
+			"the embedded model and keyword files. Use a %s library, or set ModelPath and KeywordPaths to files "+
+			"built for version %s.", native.nativeVersion(), embeddedAssetsVersion, embeddedAssetsVersion,
+			native.nativeVersion())
+	}
+
+	if len(keywordPaths) == 0 {
+		return nil, newPorcupineError(INVALID_ARGUMENT, "No valid keywords were provided.")
+	}
+
+	if len(keywordPaths) > maxKeywords {
+		return nil, newPorcupineError(INVALID_ARGUMENT, "%d keywords requested but engine supports at most %d",
+			len(keywordPaths), maxKeywords)
+	}
+
+	for _, k := range keywordPaths {
+		if !fileExists(k) {
+			return nil, newPorcupineError(INVALID_ARGUMENT, "Keyword file could not be found at %s", k)
+		}
+	}
+
+	if sensitivities == nil {
+		if porcupine.StrictSensitivities {
+			return nil, newPorcupineError(INVALID_ARGUMENT, "StrictSensitivities is set, but no sensitivities were "+
+				"given. Set Sensitivities, BuiltInSensitivities or KeywordPathSensitivities.")
+		}
+		sensitivities = make([]float32, len(keywordPaths))
+		for i := range keywordPaths {
+			sensitivities[i] = 0.5
+		}
+	} else {
+		for i, s := range sensitivities {
+			if !(s >= 0 && s <= 1) {
+				return nil, newPorcupineError(INVALID_ARGUMENT, "Sensitivity value of %g at index %d is invalid. "+
+					"Must be between [0, 1].", s, i)
+			}
+		}
+	}
+
+	if len(keywordPaths) != len(sensitivities) {
+		return nil, newPorcupineError(INVALID_ARGUMENT, "Keyword array size (%d) is not the same size as sensitivities array (%d)",
+			len(keywordPaths), len(sensitivities))
+	}
+
+	return &resolvedConfig{
+		native:        native,
+		libraryPath:   libraryPath,
+		modelPath:     modelPath,
+		keywordPaths:  keywordPaths,
+		keywordLabels: keywordLabels,
+		sensitivities: append([]float32(nil), sensitivities...),
+	}, nil
+}
+
+// Flattens `KeywordPathSensitivities` and `BuiltInSensitivities` into sensitivities in the order keywords are
+// detected: keyword files, then keyword data, then built-in keywords. Keywords without an entry use 0.5. Entries
+// for keywords that aren't configured are an error, as they are most likely a typo.
+func (porcupine *Porcupine) sensitivitiesByKeyword() ([]float32, error) {
+	var missing []string
+	sensitivityOf := func(s float32, ok bool, keyword string) float32 {
+		if !ok {
+			missing = append(missing, keyword)
+			return 0.5
+		}
+		return s
+	}
+
+	sensitivities := make([]float32, 0, len(porcupine.KeywordPaths)+len(porcupine.KeywordData)+len(porcupine.BuiltInKeywords))
+	configuredPaths := make(map[string]bool)
+	for _, keywordPath := range porcupine.KeywordPaths {
+		s, ok := porcupine.KeywordPathSensitivities[keywordPath]
+		sensitivities = append(sensitivities, sensitivityOf(s, ok, keywordPath))
+		configuredPaths[keywordPath] = true
+	}
+	for i := range porcupine.KeywordData {
+		sensitivities = append(sensitivities, sensitivityOf(0, false, fmt.Sprintf("keyword_data_%d", i)))
+	}
+	configuredBuiltIns := make(map[BuiltInKeyword]bool)
+	for _, keyword := range porcupine.BuiltInKeywords {
+		s, ok := porcupine.BuiltInSensitivities[keyword]
+		sensitivities = append(sensitivities, sensitivityOf(s, ok, string(keyword)))
+		configuredBuiltIns[keyword] = true
+	}
+
+	for keywordPath := range porcupine.KeywordPathSensitivities {
+		if !configuredPaths[keywordPath] {
+			return nil, newPorcupineError(INVALID_ARGUMENT, "KeywordPathSensitivities has an entry for '%s', which "+
+				"is not in KeywordPaths.", keywordPath)
+		}
+	}
+	for keyword := range porcupine.BuiltInSensitivities {
+		if !configuredBuiltIns[keyword] {
+			return nil, newPorcupineError(INVALID_ARGUMENT, "BuiltInSensitivities has an entry for '%s', which "+
+				"is not in BuiltInKeywords.", keyword)
+		}
+	}
+	if porcupine.StrictSensitivities && len(missing) > 0 {
+		return nil, newPorcupineError(INVALID_ARGUMENT, "StrictSensitivities is set, but no sensitivity was given "+
+			"for %s.", strings.Join(missing, ", "))
+	}
+	return sensitivities, nil
+}
+
+// Reports whether the embedded model or built-in keywords of `Language` are used, so that a language that isn't
+// embedded can still be used with its model and keyword files set explicitly.
+func (porcupine *Porcupine) needsLanguageAssets() bool {
+	if porcupine.ModelPath == "" && porcupine.ModelData == nil && porcupine.modelFS == nil {
+		return true
+	}
+	if len(porcupine.BuiltInKeywords) > 0 {
+		return true
+	}
+	if porcupine.KeywordSet != nil {
+		for _, entry := range porcupine.KeywordSet.entries {
+			if entry.builtIn != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Checks that the library, model and keyword files are all set explicitly, for use while automatic extraction is
+// disabled. Returns an error naming the missing ones.
+func (porcupine *Porcupine) checkProvisionedFiles() error {
+	var unsupported []string
+	if len(porcupine.BuiltInKeywords) > 0 {
+		unsupported = append(unsupported, "BuiltInKeywords")
+	}
+	if porcupine.ModelData != nil {
+		unsupported = append(unsupported, "ModelData")
+	}
+	if len(porcupine.KeywordData) > 0 {
+		unsupported = append(unsupported, "KeywordData")
+	}
+	if porcupine.modelFS != nil {
+		unsupported = append(unsupported, "SetModelFS")
+	}
+	if porcupine.KeywordSet != nil {
+		for _, entry := range porcupine.KeywordSet.entries {
+			if entry.builtIn != "" {
+				unsupported = append(unsupported, "built-in keywords in KeywordSet")
+				break
+			}
+		}
+	}
+	if len(unsupported) > 0 {
+		return newPorcupineError(INVALID_ARGUMENT, "Automatic extraction is disabled, so %s can't be used.",
+			strings.Join(unsupported, ", "))
+	}
+
+	nativePorcupineMutex.Lock()
+	hasDefaultLibrary := defaultLibraryPath != ""
+	nativePorcupineMutex.Unlock()
+
+	var missing []string
+	if porcupine.LibraryPath == "" && !hasDefaultLibrary {
+		missing = append(missing, "LibraryPath")
+	}
+	if porcupine.ModelPath == "" {
+		missing = append(missing, "ModelPath")
+	}
+	if len(porcupine.KeywordPaths) == 0 && (porcupine.KeywordSet == nil || porcupine.KeywordSet.Len() == 0) {
+		missing = append(missing, "KeywordPaths")
+	}
+	if len(missing) > 0 {
+		return newPorcupineError(INVALID_ARGUMENT, "Automatic extraction is disabled, so %s must be set.",
+			strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Releases resources acquired by Porcupine. If `Init` was never called or failed there is nothing to release and
+// Delete returns nil, so it is safe to `defer porcupine.Delete()` before checking the error returned by `Init`.
+// Calling Delete again after the resources were released also returns nil.
+func (porcupine *Porcupine) Delete() error {
+	porcupine.mutex.Lock()
+	defer porcupine.mutex.Unlock()
+
+	if porcupine.handle == nil {
+		return nil
+	}
+
+	if porcupine.guard != nil {
+		runtime.SetFinalizer(porcupine.guard, nil)
+		porcupine.guard = nil
+	}
+	porcupine.native.nativeDelete(porcupine)
+	porcupine.handle = nil
+	return nil
+}
+
+// Close is the same as `Delete`, so that Porcupine can be used as an `io.Closer`.
+func (porcupine *Porcupine) Close() error {
+	return porcupine.Delete()
+}
+
+// Processes a frame of the incoming audio stream and emits the detection result.
+// Frame of audio The number of samples per frame can be attained by calling
+// `FrameLength()`. The incoming audio needs to have a sample rate equal to `SampleRate()` and be 16-bit
+// linearly-encoded. Porcupine operates on single-channel audio.
+// Returns a 0 based index if keyword was detected in frame. Returns -1 if no detection was made.
+// Calls on the same instance from multiple goroutines are serialized; use separate instances to process
+// streams in parallel.
+func (porcupine *Porcupine) Process(pcm []int16) (keywordIndex int, err error) {
+	porcupine.mutex.Lock()
+	defer porcupine.mutex.Unlock()
+
+	return porcupine.process(pcm)
+}
+
+// Processes a frame like `Process`, but without checking its length, for hot loops where the caller already
+// guarantees that every frame holds exactly `FrameLength()` samples. This is unsafe: the native library reads
+// `FrameLength()` samples regardless of the length of `pcm`, so a shorter frame makes it read past the end of the
+// slice, which can crash the process or silently corrupt detections. Only an empty frame is still rejected. The
+// check it skips is a single comparison, so prefer `Process` unless profiling shows otherwise; see
+// BenchmarkProcessUnchecked.
+func (porcupine *Porcupine) ProcessUnchecked(pcm []int16) (keywordIndex int, err error) {
+	porcupine.mutex.Lock()
+	defer porcupine.mutex.Unlock()
+
+	if porcupine.handle == nil {
+		return -1, newPorcupineError(INVALID_STATE, "Porcupine has not been initialized or has been deleted.")
+	}
+
+	// the native call takes the address of the first sample, so an empty frame must never reach it
+	if len(pcm) == 0 {
+		return -1, newPorcupineError(INVALID_ARGUMENT, "Input data frame is empty. Frames must hold %d samples",
+			porcupine.frameLength)
+	}
+
+	return porcupine.processFrame(pcm)
+}
+
+// Process without locking; the caller must hold the mutex.
+func (porcupine *Porcupine) process(pcm []int16) (keywordIndex int, err error) {
+
+	if porcupine.handle == nil {
+		return -1, newPorcupineError(INVALID_STATE, "Porcupine has not been initialized or has been deleted.")
+	}
+
+	// the native call takes the address of the first sample, so an empty frame must never reach it
+	if len(pcm) == 0 {
+		return -1, newPorcupineError(INVALID_ARGUMENT, "Input data frame is empty. Frames must hold %d samples",
+			porcupine.frameLength)
+	}
+
+	if len(pcm) != porcupine.frameLength {
+		return -1, newPorcupineError(INVALID_ARGUMENT, "Input data frame size (%d) does not match required size of %d",
+			len(pcm), porcupine.frameLength)
+	}
+
+	return porcupine.processFrame(pcm)
+}
+
+// Processes a frame the caller has checked to hold `frameLength` samples. Must be called with the mutex held and
+// an initialized engine.
+func (porcupine *Porcupine) processFrame(pcm []int16) (keywordIndex int, err error) {
+	// call process
+	var start time.Time
+	if porcupine.Observer != nil {
+		start = time.Now()
+	}
+	ret, index := porcupine.native.nativeProcess(porcupine, pcm)
+	// the guard must not release the engine while it processes the frame
+	runtime.KeepAlive(porcupine)
+	if PvStatus(ret) != SUCCESS {
+		return -1, newNativeError(porcupine.native, ret, "Process audio frame failed")
+	}
+	if porcupine.Observer != nil {
+		porcupine.Observer.OnProcess(time.Since(start))
+	}
+	if index < -1 || index >= len(porcupine.keywordLabels) {
+		return -1, newPorcupineError(RUNTIME_ERROR, "Native library reported keyword index %d, but %d keywords are "+
+			"configured", index, len(porcupine.keywordLabels))
+	}
+
+	porcupine.frameCount++
+	if index >= 0 {
+		if porcupine.disabledKeywords[index] || porcupine.inCooldown(index) {
+			return -1, nil
+		}
+		porcupine.lastDetectionFrames[index] = porcupine.frameCount - 1
+		porcupine.recordDetection(porcupine.newDetection(index, porcupine.frameCount-1))
+		if porcupine.Observer != nil {
+			porcupine.Observer.OnDetection(index, porcupine.keywordLabels[index])
+		}
+	}
+
+	return index, nil
+}
+
+// Reports whether a detection of `keywordIndex` in the current frame falls within `MinDetectionGap` of the
+// previous detection of the same keyword.
+func (porcupine *Porcupine) inCooldown(keywordIndex int) bool {
+	lastFrame := porcupine.lastDetectionFrames[keywordIndex]
+	if porcupine.MinDetectionGap <= 0 || lastFrame < 0 {
+		return false
+	}
+	elapsed := porcupine.framesDuration(porcupine.frameCount - 1 - lastFrame)
+	return elapsed < porcupine.MinDetectionGap
+}
+
+// Ends the `MinDetectionGap` cooldown of every keyword, so that the next detection of any keyword is reported
+// immediately. Useful for re-arming once a dialog triggered by a wake word has completed.
+func (porcupine *Porcupine) ArmAll() {
+	porcupine.mutex.Lock()
+	defer porcupine.mutex.Unlock()
+
+	porcupine.armAll()
+}
+
+// ArmAll without locking; the caller must hold the mutex.
+func (porcupine *Porcupine) armAll() {
+	for i := range porcupine.lastDetectionFrames {
+		porcupine.lastDetectionFrames[i] = -1
+	}
+}
+
+// Ends the `MinDetectionGap` cooldown of the keyword at `index`, so that its next detection is reported
+// immediately. Does nothing if `index` is out of range.
+func (porcupine *Porcupine) Arm(index int) {
+	porcupine.mutex.Lock()
+	defer porcupine.mutex.Unlock()
+
+	if index >= 0 && index < len(porcupine.lastDetectionFrames) {
+		porcupine.lastDetectionFrames[index] = -1
+	}
+}
+
+// Enables or disables reporting detections of the keyword at `index`, e.g. to ignore "alexa" during a phone call
+// without creating a new instance. Detections of a disabled keyword are reported as -1 and aren't recorded or
+// passed to the `Observer`. This is a mask applied to the results of the native engine, which still listens for
+// every keyword, so disabling keywords doesn't make processing any faster. All keywords are enabled by `Init`.
+// Does nothing if `index` is out of range.
+func (porcupine *Porcupine) SetKeywordEnabled(index int, enabled bool) {
+	porcupine.mutex.Lock()
+	defer porcupine.mutex.Unlock()
+
+	if index >= 0 && index < len(porcupine.disabledKeywords) {
+		porcupine.disabledKeywords[index] = !enabled
+	}
+}
+
+// Processes audio of any length and returns the indices of the keywords detected in it, in order. The audio is
+// split into frames of `FrameLength()` samples. Samples left over after the last full frame are retained and
+// processed with the audio passed to the next call once a full frame has accumulated, so a stream can be passed in
+// chunks of any size. `Init` discards any retained samples.
+func (porcupine *Porcupine) ProcessBuffer(pcm []int16) ([]int, error) {
+	porcupine.mutex.Lock()
+	defer porcupine.mutex.Unlock()
+
+	if porcupine.handle == nil {
+		return nil, newPorcupineError(INVALID_STATE, "Porcupine has not been initialized or has been deleted.")
+	}
+	return porcupine.processBuffer(pcm)
+}
+
+// Processes one channel of interleaved multi-channel audio, e.g. the far-field microphone of an array, and returns
+// the indices of the keywords detected in it, in order. `channelIndex` is the 0 based index of the channel within
+// each group of `channels` samples. Like `ProcessBuffer`, the channel is split into frames and samples left over
+// after the last full frame are retained for the next call, so `interleaved` can be of any length that holds whole
+// groups of `channels` samples. Use `DownmixToMono` to mix all channels instead.
+func (porcupine *Porcupine) ProcessChannel(interleaved []int16, channels, channelIndex int) ([]int, error) {
+	if channels < 1 {
+		return nil, newPorcupineError(INVALID_ARGUMENT, "Number of channels must be at least 1, but got %d.", channels)
+	}
+	if channelIndex < 0 || channelIndex >= channels {
+		return nil, newPorcupineError(INVALID_ARGUMENT, "Channel index %d is out of range for %d channels.",
+			channelIndex, channels)
+	}
+	if len(interleaved)%channels != 0 {
+		return nil, newPorcupineError(INVALID_ARGUMENT, "Interleaved audio of %d samples doesn't hold whole groups "+
+			"of %d channels.", len(interleaved), channels)
+	}
+
+	porcupine.mutex.Lock()
+	defer porcupine.mutex.Unlock()
+
+	if porcupine.handle == nil {
+		return nil, newPorcupineError(INVALID_STATE, "Porcupine has not been initialized or has been deleted.")
+	}
+
+	pcm := make([]int16, len(interleaved)/channels)
+	for i := range pcm {
+		pcm[i] = interleaved[i*channels+channelIndex]
+	}
+	return porcupine.processBuffer(pcm)
+}
+
+// Splits audio into frames and processes them, retaining samples left over after the last full frame. Must be
+// called with the mutex held.
+func (porcupine *Porcupine) processBuffer(pcm []int16) ([]int, error) {
+	var keywordIndices []int
+	for len(pcm) > 0 {
+		var frame []int16
+		if len(porcupine.pendingPCM) == 0 && len(pcm) >= porcupine.frameLength {
+			frame, pcm = pcm[:porcupine.frameLength], pcm[porcupine.frameLength:]
+		} else {
+			n := porcupine.frameLength - len(porcupine.pendingPCM)
+			if n > len(pcm) {
+				n = len(pcm)
+			}
+			porcupine.pendingPCM = append(porcupine.pendingPCM, pcm[:n]...)
+			pcm = pcm[n:]
+			if len(porcupine.pendingPCM) < porcupine.frameLength {
+				break
+			}
+			frame = porcupine.pendingPCM
+			porcupine.pendingPCM = porcupine.pendingPCM[:0]
+		}
+
+		keywordIndex, err := porcupine.process(frame)
+		if err != nil {
+			return keywordIndices, err
+		}
+		if keywordIndex >= 0 {
+			keywordIndices = append(keywordIndices, keywordIndex)
+		}
+	}
+	return keywordIndices, nil
+}
+
+// Processes the same frame with several instances, e.g. instances with different keywords or sensitivities
+// listening to one microphone, and returns the result of each instance in order. Every instance processes the frame
+// even if an earlier one fails, so that their streams stay aligned; the result of a failed instance is -1 and the
+// first error is returned, wrapped with the index of the instance.
+func ProcessMultiple(frame []int16, instances ...*Porcupine) ([]int, error) {
+	results := make([]int, len(instances))
+	var firstErr error
+	for i, instance := range instances {
+		keywordIndex, err := instance.Process(frame)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("instance %d: %w", i, err)
+		}
+		results[i] = keywordIndex
+	}
+	return results, firstErr
+}
+
+// Same as `Process`, but returns the label of the detected keyword instead of its index, or "" if no keyword was
+// detected. Labels are the names of built-in keywords and the file names of keyword files without their
+// extension, e.g. "porcupine_linux" for "/path/to/porcupine_linux.ppn".
+func (porcupine *Porcupine) ProcessLabel(pcm []int16) (string, error) {
+	keywordIndex, err := porcupine.Process(pcm)
+	if err != nil || keywordIndex < 0 {
+		return "", err
+	}
+	return porcupine.keywordLabels[keywordIndex], nil
+}
+
+// Same as `Process`, but also returns a score per keyword when the native library provides them. Scores require a
+// library whose `pv_porcupine_process` reports them, which no version does as of 1.9. With other libraries the
+// frame is processed as by `Process` and `scores` is nil, so callers should treat nil scores as "not available"
+// rather than as zero. Errors are only returned for frames that fail to process.
+func (porcupine *Porcupine) ProcessWithScores(pcm []int16) (keywordIndex int, scores []float32, err error) {
+	keywordIndex, err = porcupine.Process(pcm)
+	return keywordIndex, nil, err
+}
+
+// Same as `Process`, but returns the detection with its label, frame index, timestamp and sample offset, or nil if
+// no keyword was detected in the frame.
+func (porcupine *Porcupine) ProcessDetailed(pcm []int16) (*Detection, error) {
+	porcupine.mutex.Lock()
+	defer porcupine.mutex.Unlock()
+
+	keywordIndex, err := porcupine.process(pcm)
+	if err != nil || keywordIndex < 0 {
+		return nil, err
+	}
+	detection := porcupine.newDetection(keywordIndex, porcupine.frameCount-1)
+	return &detection, nil
+}
+
+// Same as `Process`, but returns the indices of all keywords detected in the frame, in ascending order, or an
+// empty slice if none was. The native library reports at most one keyword per frame as of version 1.9, so the
+// slice currently holds 0 or 1 elements; callers should nevertheless handle any number so that they keep working
+// once the library reports overlapping detections.
+func (porcupine *Porcupine) ProcessAll(pcm []int16) ([]int, error) {
+	keywordIndex, err := porcupine.Process(pcm)
+	if err != nil || keywordIndex < 0 {
+		return []int{}, err
+	}
+	return []int{keywordIndex}, nil
+}
+
+// Same as `Process`, but returns `ctx.Err()` if `ctx` is done before the native library has processed the frame,
+// which bounds the latency of a server even if the library hangs. The native call can't be interrupted and keeps
+// running in the background; a detection it reports is recorded in `RecentDetections` but not returned. Calls
+// that follow, including `Delete`, wait for it to complete. The frame is copied, so `pcm` can be reused as soon as
+// ProcessContext returns.
+func (porcupine *Porcupine) ProcessContext(ctx context.Context, pcm []int16) (keywordIndex int, err error) {
+	if err := ctx.Err(); err != nil {
+		return -1, err
+	}
+
+	type processResult struct {
+		keywordIndex int
+		err          error
+	}
+	done := make(chan processResult, 1)
+	frame := append([]int16(nil), pcm...)
+	go func() {
+		keywordIndex, err := porcupine.Process(frame)
+		done <- processResult{keywordIndex, err}
+	}()
+
+	select {
+	case result := <-done:
+		return result.keywordIndex, result.err
+	case <-ctx.Done():
+		return -1, ctx.Err()
+	}
+}
+
+// Same as `Process`, but also returns a score for the detected keyword. The native library does not report
+// detection scores as of version 1.9, so the score is the sensitivity the detected keyword was configured with.
+// Callers can rely on a higher score meaning a more permissive detection; the value will become the native score
+// once the library exposes one. The score is 0 if no keyword was detected.
+func (porcupine *Porcupine) ProcessWithScore(pcm []int16) (keywordIndex int, score float32, err error) {
+	porcupine.mutex.Lock()
+	defer porcupine.mutex.Unlock()
+
+	keywordIndex, err = porcupine.process(pcm)
+	if err != nil || keywordIndex < 0 {
+		return keywordIndex, 0, err
+	}
+	return keywordIndex, porcupine.sensitivities[keywordIndex], nil
+}
+
+// Processes a frame of float audio with samples within [-1, 1], as delivered by many capture libraries. Each
+// sample `x` is converted to 16-bit PCM as `round(x * 32767)`, clamped to [-32768, 32767], so values outside
+// [-1, 1] clip at full scale instead of wrapping around. NaN and Inf samples are handled according to
+// `NonFinitePolicy`. The converted frame is then passed to `Process`.
+func (porcupine *Porcupine) ProcessFloat32(pcm []float32) (keywordIndex int, err error) {
+	return porcupine.ProcessFloat32Into(pcm, make([]int16, len(pcm)))
+}
+
+// Same as `ProcessFloat32`, but converts the samples into `scratch` instead of allocating a new frame on every
+// call. `scratch` must hold `FrameLength()` samples and can be reused across calls.
+func (porcupine *Porcupine) ProcessFloat32Into(pcm []float32, scratch []int16) (keywordIndex int, err error) {
+	if len(scratch) != len(pcm) {
+		return -1, newPorcupineError(INVALID_ARGUMENT, "Scratch buffer size (%d) does not match input data frame size (%d)",
+			len(scratch), len(pcm))
+	}
+
+	nonFinite := float32ToInt16Into(scratch, pcm)
+	if nonFinite > 0 {
+		if porcupine.NonFinitePolicy == REJECT_NON_FINITE {
+			return -1, newPorcupineError(INVALID_ARGUMENT, "Input data frame contains %d NaN or Inf samples", nonFinite)
+		}
+		porcupine.mutex.Lock()
+		porcupine.stats.NonFiniteSamples += uint64(nonFinite)
+		porcupine.mutex.Unlock()
+	}
+
+	return porcupine.Process(scratch)
+}
+
+// Processes a frame of 16-bit little-endian PCM bytes, the byte order used by WAV files. Same as
+// `ProcessBytesLE`.
+func (porcupine *Porcupine) ProcessBytes(pcm []byte) (keywordIndex int, err error) {
+	return porcupine.ProcessBytesLE(pcm)
+}
+
+// Processes a frame of 16-bit little-endian PCM bytes. `pcm` must hold `FrameLength()` samples
+// (`FrameLength() * 2` bytes). The byte order of the host does not matter.
+func (porcupine *Porcupine) ProcessBytesLE(pcm []byte) (keywordIndex int, err error) {
+	return porcupine.ProcessBytesOrder(pcm, binary.LittleEndian)
+}
+
+// Processes a frame of 16-bit big-endian PCM bytes. `pcm` must hold `FrameLength()` samples
+// (`FrameLength() * 2` bytes). The byte order of the host does not matter.
+func (porcupine *Porcupine) ProcessBytesBE(pcm []byte) (keywordIndex int, err error) {
+	return porcupine.ProcessBytesOrder(pcm, binary.BigEndian)
+}
+
+// Processes a frame of 16-bit PCM bytes in the given byte order. `pcm` must hold `FrameLength()` samples
+// (`FrameLength() * 2` bytes).
+func (porcupine *Porcupine) ProcessBytesOrder(pcm []byte, order binary.ByteOrder) (keywordIndex int, err error) {
+	return porcupine.processBytesInto(pcm, make([]int16, len(pcm)/2), order)
+}
+
+// Processes a frame of 16-bit little-endian PCM bytes, converting it into `scratch`. `pcm` must hold
+// `FrameLength()` samples (`FrameLength() * 2` bytes) and `scratch` must hold `FrameLength()` samples. `scratch` can
+// be reused across calls to avoid allocating a new frame each time.
+func (porcupine *Porcupine) ProcessBytesInto(pcm []byte, scratch []int16) (keywordIndex int, err error) {
+	return porcupine.processBytesInto(pcm, scratch, binary.LittleEndian)
+}
+
+func (porcupine *Porcupine) processBytesInto(pcm []byte, scratch []int16, order binary.ByteOrder) (int, error) {
+	if len(pcm) != len(scratch)*2 {
+		return -1, newPorcupineError(INVALID_ARGUMENT, "Input data size (%d bytes) does not match scratch buffer size (%d samples)",
+			len(pcm), len(scratch))
+	}
+
+	decodePCM(scratch, pcm, order)
+	return porcupine.Process(scratch)
+}
+
+// Returns a copy of the most recent detections, oldest first. At most `DetectionHistorySize` detections are
+// retained.
+func (porcupine *Porcupine) RecentDetections() []Detection {
+	porcupine.mutex.Lock()
+	defer porcupine.mutex.Unlock()
+
+	detections := make([]Detection, 0, len(porcupine.recentDetections))
+	detections = append(detections, porcupine.recentDetections[porcupine.recentDetectionsPos:]...)
+	detections = append(detections, porcupine.recentDetections[:porcupine.recentDetectionsPos]...)
+	return detections
+}
+
+// Creates a Detection for a keyword detected in the last processed frame, for callers that don't hold the mutex.
+func (porcupine *Porcupine) lastDetection(keywordIndex int) Detection {
+	porcupine.mutex.Lock()
+	defer porcupine.mutex.Unlock()
+
+	return porcupine.newDetection(keywordIndex, porcupine.frameCount-1)
+}
+
+// Creates a Detection for a keyword detected in the frame at `frameIndex`, counted from the start of the stream.
+func (porcupine *Porcupine) newDetection(keywordIndex int, frameIndex int64) Detection {
+	return Detection{
+		Index:        keywordIndex,
+		Keyword:      porcupine.keywordLabels[keywordIndex],
+		FrameIndex:   frameIndex,
+		Timestamp:    porcupine.framesDuration(frameIndex + 1),
+		SampleOffset: (frameIndex + 1) * int64(porcupine.frameLength),
+	}
+}
+
+// Returns the duration of audio processed since `Init` or the last `Reset`, i.e. the time from the start of the
+// stream to the end of the last processed frame. Detections carry the same timestamp in `Detection.Timestamp`.
+func (porcupine *Porcupine) ProcessedDuration() time.Duration {
+	porcupine.mutex.Lock()
+	defer porcupine.mutex.Unlock()
+
+	return porcupine.framesDuration(porcupine.frameCount)
+}
+
+// Returns the duration of `frames` frames of audio.
+func (porcupine *Porcupine) framesDuration(frames int64) time.Duration {
+	if porcupine.native == nil {
+		return 0
+	}
+	sampleRate := porcupine.native.nativeSampleRate()
+	if sampleRate == 0 {
+		return 0
+	}
+	return samplesDuration(frames*int64(porcupine.frameLength), sampleRate)
+}
+
+func (porcupine *Porcupine) recordDetection(detection Detection) {
+	historySize := porcupine.DetectionHistorySize
+	if historySize <= 0 {
+		historySize = defaultDetectionHistorySize
+	}
+
+	if len(porcupine.recentDetections) < historySize {
+		porcupine.recentDetections = append(porcupine.recentDetections, detection)
+		return
+	}
+	porcupine.recentDetections[porcupine.recentDetectionsPos] = detection
+	porcupine.recentDetectionsPos = (porcupine.recentDetectionsPos + 1) % len(porcupine.recentDetections)
+}
+
+// Returns the labels of the keywords the instance detects, in the order of the indices returned by `Process`:
+// keyword files first, then keyword data, then built-in keywords, or the order of `KeywordSet`. Returns nil if the
+// instance has not been initialized.
+func (porcupine *Porcupine) Keywords() []string {
+	porcupine.mutex.Lock()
+	defer porcupine.mutex.Unlock()
+
+	return append([]string(nil), porcupine.keywordLabels...)
+}
+
+// Returns the number of keywords the instance detects, or 0 if it has not been initialized.
+func (porcupine *Porcupine) NumKeywords() int {
+	porcupine.mutex.Lock()
+	defer porcupine.mutex.Unlock()
+
+	return len(porcupine.keywordLabels)
+}
+
+// Config struct
+type Config struct {
+	// AccessKey the instance was created with. Redacted by `String`.
+	AccessKey string
+
+	// Path and version of the native library.
+	LibraryPath    string
+	LibraryVersion string
+
+	// Path of the model file, e.g. the extracted default model.
+	ModelPath string
+
+	// Number of samples per frame expected by the engine.
+	FrameLength int
+
+	// Labels, paths and sensitivities of the keywords, in the order of the indices returned by `Process`.
+	Keywords      []string
+	KeywordPaths  []string
+	Sensitivities []float32
+}
+
+// Formats the configuration on a single line for logging. The AccessKey is redacted.
+func (config Config) String() string {
+	keywords := make([]string, len(config.Keywords))
+	for i, keyword := range config.Keywords {
+		keywords[i] = fmt.Sprintf("%s (%s, sensitivity %g)", keyword, config.KeywordPaths[i], config.Sensitivities[i])
+	}
+	accessKey := "not set"
+	if config.AccessKey != "" {
+		accessKey = "redacted"
+	}
+	return fmt.Sprintf("library %s (version %s), model %s, keywords [%s], access key %s", config.LibraryPath,
+		config.LibraryVersion, config.ModelPath, strings.Join(keywords, ", "), accessKey)
+}
+
+// Returns the configuration the instance ended up using after `Init` filled in defaults, such as the paths of the
+// extracted library, model and built-in keyword files. Returns an empty configuration if the instance has not been
+// initialized.
+func (porcupine *Porcupine) Config() Config {
+	porcupine.mutex.Lock()
+	defer porcupine.mutex.Unlock()
+
+	if porcupine.native == nil || porcupine.keywordLabels == nil {
+		return Config{}
+	}
+	return Config{
+		AccessKey:      porcupine.AccessKey,
+		LibraryPath:    porcupine.libraryPath,
+		LibraryVersion: porcupine.native.nativeVersion(),
+		ModelPath:      porcupine.modelPath,
+		FrameLength:    porcupine.frameLength,
+		Keywords:       append([]string(nil), porcupine.keywordLabels...),
+		KeywordPaths:   append([]string(nil), porcupine.keywordPaths...),
+		Sensitivities:  append([]float32(nil), porcupine.sensitivities...),
+	}
+}
+
+// Sources the model from `path` in `fsys`, e.g. an `embed.FS` of the application, instead of `ModelPath` or the
+// model bundled with the package. Entries of `KeywordPaths` that are valid `fs.FS` paths, i.e. unrooted and
+// slash-separated, are read from `fsys` as well, while absolute paths are still read from disk. Since the native
+// library only loads files, `Init` writes the contents once to the extraction directory, like `ModelData`. Must be
+// called before `Init`; a nil `fsys` restores the default.
+func (porcupine *Porcupine) SetModelFS(fsys fs.FS, path string) {
+	porcupine.modelFS = fsys
+	porcupine.modelFSPath = path
+}
+
+// Returns a snapshot of the counters collected since the instance was created.
+func (porcupine *Porcupine) Stats() Stats {
+	porcupine.mutex.Lock()
+	defer porcupine.mutex.Unlock()
+
+	return porcupine.stats
+}
+
+// Returns the time and memory taken by the last `Init`, e.g. for capacity planning when running many instances.
+// Returns zero values if the instance has not been initialized.
+func (porcupine *Porcupine) InitStats() InitStats {
+	porcupine.mutex.Lock()
+	defer porcupine.mutex.Unlock()
+	return porcupine.initStats
+}
+
+// Returns the resident memory of the process in bytes, or 0 if the platform doesn't report it.
+func residentMemory() int64 {
+	statm, err := ioutil.ReadFile("/proc/self/statm")
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(string(statm))
+	if len(fields) < 2 {
+		return 0
+	}
+	pages, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return pages * int64(os.Getpagesize())
+}
+
+func keywordLabelFromPath(keywordPath string) string {
+	base := filepath.Base(keywordPath)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+func getOS() (string, error) {
+	goos, goarch := platformDetector()
+	switch goos {
+	case "darwin":
+		return "mac", nil
+	case "linux":
+		if goarch == "arm" || goarch == "arm64" {
+			if _, err := getRaspberryPiCPU(goarch); err != nil {
+				return "", err
+			}
+			return "raspberry-pi", nil
+		}
+		return "linux", nil
+	case "windows":
+		return "windows", nil
+	default:
+		return "", &UnsupportedPlatformError{OS: goos, Arch: goarch}
+	}
+}
+
+func extractDefaultModel() (string, error) {
+	modelPath := "embedded/lib/common/porcupine_params.pv"
+	return extractFile(modelPath, getVersionedExtractionDir())
+}
+
+func extractKeywordFiles(language Language) (map[string]string, error) {
+	keywordFiles, err := embeddedKeywordFiles(language, osName)
+	if err != nil {
+		return nil, err
+	}
+
+	extractedKeywords := make(map[string]string)
+	for keywordName, keywordPath := range keywordFiles {
+		extractedKeywords[keywordName], err = extractFile(keywordPath, getVersionedExtractionDir())
+		if err != nil {
+			return nil, err
+		}
+	}
+	return extractedKeywords, nil
+}
+
+// Returns the names of the English keyword files embedded for the current platform, sorted by name, without
+// extracting them. The names are the values accepted as `BuiltInKeyword`.
+func AvailableKeywordFiles() ([]string, error) {
+	if !embeddedAssets {
+		return nil, newPorcupineError(INVALID_ARGUMENT, "Keyword files are not embedded in builds with the "+
+			"porcupine_noembed tag.")
+	}
+	platform, err := getOS()
+	if err != nil {
+		return nil, err
+	}
+	keywordFiles, err := embeddedKeywordFiles(ENGLISH, platform)
+	if err != nil {
+		return nil, err
+	}
+	return keywordNames(keywordFiles), nil
+}
+
+// Returns the embedded keyword files of a language for a platform, keyed by keyword name.
+func embeddedKeywordFiles(language Language, platform string) (map[string]string, error) {
+	keywordDirPath := "embedded/resources/keyword_files" + language.assetSuffix() + "/" + platform
+	keywordFiles, err := embeddedFS.ReadDir(keywordDirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	keywords := make(map[string]string)
+	for _, keywordFile := range keywordFiles {
+		keywordName := keywordNameFromFile(keywordFile.Name(), platform)
+		keywords[keywordName] = keywordDirPath + "/" + keywordFile.Name()
+	}
+	return keywords, nil
+}
+
+// Keyword files are named `<keyword>_<platform>.ppn`. Keywords may contain spaces and underscores, so only the
+// platform suffix is removed.
+func keywordNameFromFile(fileName string, platform string) string {
+	return strings.TrimSuffix(fileName, "_"+platform+".ppn")
+}
+
+func extractLib() (string, error) {
+	libPath, err := embeddedLibraryFile()
+	if err != nil {
+		return "", err
+	}
+	return extractFile(libPath, getVersionedExtractionDir())
+}
+
+// Returns the path of the embedded native library for the current platform.
+func embeddedLibraryFile() (string, error) {
+	goos, goarch := platformDetector()
+	switch goos + "/" + goarch {
+	case "darwin/amd64":
+		return "embedded/lib/mac/x86_64/libpv_porcupine.dylib", nil
+	case "linux/amd64":
+		return "embedded/lib/linux/x86_64/libpv_porcupine.so", nil
+	case "linux/arm", "linux/arm64":
+		cpu, err := getRaspberryPiCPU(goarch)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("embedded/lib/raspberry-pi/%s/libpv_porcupine.so", cpu), nil
+	case "windows/amd64":
+		return "embedded/lib/windows/amd64/libpv_porcupine.dll", nil
+	case "windows/386":
+		// 32-bit builds are only supported if a library for them was bundled by copy.sh
+		libPath := "embedded/lib/windows/x86/libpv_porcupine.dll"
+		if _, err := fs.Stat(embeddedFS, libPath); err != nil {
+			return "", &UnsupportedPlatformError{OS: goos, Arch: goarch}
+		}
+		return libPath, nil
+	default:
+		return "", &UnsupportedPlatformError{OS: goos, Arch: goarch}
+	}
+}
+
+// Returns the name of the Raspberry Pi library directory for the CPU of this machine, e.g. "cortex-a72" or
+// "cortex-a72-aarch64" for 64-bit builds, based on the CPU part reported in /proc/cpuinfo.
+func getRaspberryPiCPU(goarch string) (string, error) {
+	cpuInfo, err := cpuInfoReader()
+	if err != nil {
+		return "", fmt.Errorf("Failed to detect CPU of linux/%s: %v", goarch, err)
+	}
+
+	cpuPart := ""
+	for _, line := range strings.Split(string(cpuInfo), "\n") {
+		if strings.HasPrefix(line, "CPU part") {
+			if i := strings.Index(line, ":"); i >= 0 {
+				cpuPart = strings.TrimSpace(line[i+1:])
+				break
+			}
+		}
+	}
+
+	var cpu string
+	switch cpuPart {
+	case "0xb76":
+		cpu = "arm11"
+	case "0xc07":
+		cpu = "cortex-a7"
+	case "0xd03":
+		cpu = "cortex-a53"
+	case "0xd08":
+		cpu = "cortex-a72"
+	default:
+		if cpuPart == "" {
+			cpuPart = "unknown CPU"
+		} else {
+			cpuPart = "CPU part " + cpuPart
+		}
+		return "", &UnsupportedPlatformError{OS: "linux", Arch: goarch, CPU: cpuPart}
+	}
+
+	if goarch == "arm64" {
+		if cpu != "cortex-a53" && cpu != "cortex-a72" {
+			return "", &UnsupportedPlatformError{OS: "linux", Arch: goarch, CPU: cpu}
+		}
+		cpu += "-aarch64"
+	}
+	return cpu, nil
+}
+
+func extractFile(srcFile string, dstDir string) (string, error) {
+	bytes, readErr := embeddedFS.ReadFile(srcFile)
+	if readErr != nil {
+		return "", readErr
+	}
+
+	start := time.Now()
+	extractedFilepath := filepath.Join(dstDir, srcFile)
+	if extractedFileMatches(extractedFilepath, bytes) {
+		logf("%s is already extracted", extractedFilepath)
+		return extractedFilepath, nil
+	}
+	if writeErr := writeFileAtomic(extractedFilepath, bytes); writeErr != nil {
+		logf("failed to extract %s: %v", extractedFilepath, writeErr)
+		return "", writeErr
+	}
+	logf("extracted %s (%d bytes)", extractedFilepath, len(bytes))
+	reportExtraction(ExtractionEvent{
+		File:     extractedFilepath,
+		Size:     int64(len(bytes)),
+		Duration: time.Since(start),
+	})
+	return extractedFilepath, nil
+}
+
+// The native library only loads models and keywords from files, so in-memory data is written to the extraction
+// directory, named by its hash so that the same data is only written once.
+func writeDataFile(data []byte, extension string) (string, error) {
+	if len(data) == 0 {
+		return "", newPorcupineError(INVALID_ARGUMENT, "Model or keyword data is empty.")
+	}
+
+	dataPath := filepath.Join(getExtractionDir(), "data", fmt.Sprintf("%x", sha256.Sum256(data))+extension)
+	if extractedFileMatches(dataPath, data) {
+		return dataPath, nil
+	}
+	if err := writeFileAtomic(dataPath, data); err != nil {
+		return "", newPorcupineError(IO_ERROR, "Could not write data to '%s': %v", dataPath, err)
+	}
+	return dataPath, nil
+}
+
+// Reads a model or keyword file from a user-provided file system and writes it like in-memory data.
+func writeFSFile(fsys fs.FS, path string, extension string) (string, error) {
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return "", newPorcupineError(IO_ERROR, "Could not read '%s' from model file system: %v", path, err)
+	}
+	return writeDataFile(data, extension)
+}
+
+// Whether a previously extracted file has the given contents, so that it doesn't need to be written again.
+func extractedFileMatches(path string, data []byte) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() != int64(len(data)) {
+		return false
+	}
+	existing, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	return sha256.Sum256(existing) == sha256.Sum256(data)
+}
+
+// Writes to a temporary file in the same directory and renames it into place, so that other processes
+// extracting to the same directory, and libraries already loaded from the path, never see a partial file.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return err
+	}
+	tmpFile, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+	_, err = tmpFile.Write(data)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, 0777)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+	}
+	return err
+}
+
+// ExtractionEvent struct
+type ExtractionEvent struct {
+	// Path the asset was extracted to.
+	File string
+
+	// Size of the asset in bytes.
+	Size int64
+
+	// Time taken to write the asset.
+	Duration time.Duration
+}
+
+// Registers a callback that is called as each embedded asset (model, keyword files and library) is extracted,
+// e.g. to show progress on slow storage where extraction can take several seconds. Assets that were extracted
+// before the callback was registered, such as those extracted when the package is initialized, are reported
+// immediately, up to the 256 most recently extracted files. Files that were already extracted with the same
+// contents are not written again and not reported. Pass nil to stop reporting. Reporting is off by default.
+func SetExtractionProgress(callback func(ExtractionEvent)) {
+	extractionProgressMutex.Lock()
+	extractionProgress = callback
+	past := append([]ExtractionEvent(nil), extractionEvents...)
+	extractionProgressMutex.Unlock()
+
+	if callback != nil {
+		for _, event := range past {
+			callback(event)
+		}
+	}
+}
+
+// Number of past extractions kept for callbacks registered later, so that extracting to many directories doesn't
+// grow the history without bound.
+const maxExtractionEvents = 256
+
+func reportExtraction(event ExtractionEvent) {
+	extractionProgressMutex.Lock()
+	// a file extracted again, e.g. after the extraction directory was cleaned up, replaces its earlier event
+	for i, past := range extractionEvents {
+		if past.File == event.File {
+			extractionEvents = append(extractionEvents[:i], extractionEvents[i+1:]...)
+			break
+		}
+	}
+	if len(extractionEvents) >= maxExtractionEvents {
+		extractionEvents = append(extractionEvents[:0], extractionEvents[1:]...)
+	}
+	extractionEvents = append(extractionEvents, event)
+	callback := extractionProgress
+	extractionProgressMutex.Unlock()
+
+	if callback != nil {
+		callback(event)
 	}
-	return extractedFilepath
 }
@@ -11,8 +11,8 @@
 
 // Go binding for Porcupine wake word engine. It detects utterances of given keywords within an incoming stream of
 // audio in real-time. It processes incoming audio in consecutive frames and for each frame emits the detection result.
-// The number of samples per frame can be attained by calling `.FrameLength`. The incoming audio needs to have a
-// sample rate equal to `.SampleRate` and be 16-bit linearly-encoded. Porcupine operates on single-channel audio.
+// The number of samples per frame can be attained by calling `FrameLength()`. The incoming audio needs to have a
+// sample rate equal to `SampleRate()` and be 16-bit linearly-encoded. Porcupine operates on single-channel audio.
 
 // +build linux darwin
 
@@ -48,12 +48,30 @@ int32_t pv_porcupine_init_wrapper(void *f, const char *model_path, int32_t num_k
 	return ((pv_porcupine_init_func) f)(model_path, num_keywords, keyword_paths, sensitivities, object);
 }
 
+typedef int32_t (*pv_porcupine_init_with_access_key_func)(const char *, const char *, int32_t, const char * const *, const float *, void **);
+
+int32_t pv_porcupine_init_with_access_key_wrapper(void *f, const char *access_key, const char *model_path, int32_t num_keywords, const char * const *keyword_paths, const float *sensitivities, void **object) {
+	return ((pv_porcupine_init_with_access_key_func) f)(access_key, model_path, num_keywords, keyword_paths, sensitivities, object);
+}
+
 typedef int32_t (*pv_porcupine_process_func)(void *, const int16_t *, int32_t *);
 
 int32_t pv_porcupine_process_wrapper(void *f, void *object, const int16_t *pcm, int32_t *keyword_index) {
 	return ((pv_porcupine_process_func) f)(object, pcm, keyword_index);
 }
 
+typedef int32_t (*pv_get_error_stack_func)(char ***, int32_t *);
+
+int32_t pv_get_error_stack_wrapper(void *f, char ***message_stack, int32_t *message_stack_depth) {
+	return ((pv_get_error_stack_func) f)(message_stack, message_stack_depth);
+}
+
+typedef void (*pv_free_error_stack_func)(char **);
+
+void pv_free_error_stack_wrapper(void *f, char **message_stack) {
+	((pv_free_error_stack_func) f)(message_stack);
+}
+
 typedef void (*pv_porcupine_delete_func)(void *);
 
 void pv_porcupine_delete_wrapper(void *f, void *object) {
@@ -64,69 +82,166 @@ void pv_porcupine_delete_wrapper(void *f, void *object) {
 import "C"
 
 import (
+	"fmt"
 	"unsafe"
 )
 
-// private vars
-var (
-	lib = C.dlopen(C.CString(libName), C.RTLD_NOW)
+// native library loaded with dlopen
+type nativePorcupineType struct {
+	lib unsafe.Pointer
 
-	pv_porcupine_init_ptr         = C.dlsym(lib, C.CString("pv_porcupine_init"))
-	pv_porcupine_process_ptr      = C.dlsym(lib, C.CString("pv_porcupine_process"))
-	pv_sample_rate_ptr            = C.dlsym(lib, C.CString("pv_sample_rate"))
-	pv_porcupine_version_ptr      = C.dlsym(lib, C.CString("pv_porcupine_version"))
-	pv_porcupine_frame_length_ptr = C.dlsym(lib, C.CString("pv_porcupine_frame_length"))
-	pv_porcupine_delete_ptr       = C.dlsym(lib, C.CString("pv_porcupine_delete"))
-)
+	pv_porcupine_init_ptr         unsafe.Pointer
+	pv_porcupine_process_ptr      unsafe.Pointer
+	pv_sample_rate_ptr            unsafe.Pointer
+	pv_porcupine_version_ptr      unsafe.Pointer
+	pv_porcupine_frame_length_ptr unsafe.Pointer
+	pv_porcupine_delete_ptr       unsafe.Pointer
+
+	// only available in Porcupine 2.0 and later
+	pv_get_error_stack_ptr  unsafe.Pointer
+	pv_free_error_stack_ptr unsafe.Pointer
+}
 
-func (np nativePorcupineType) nativeInit(porcupine *Porcupine) (status PvStatus) {
+func loadNativeLibrary(libPath string) (*nativePorcupineType, error) {
+	libPathC := C.CString(libPath)
+	defer C.free(unsafe.Pointer(libPathC))
+
+	lib := C.dlopen(libPathC, dlopenMode(getLibraryLoadFlags()))
+	if lib == nil {
+		return nil, fmt.Errorf("Failed to load Porcupine library at %s: %s", libPath, C.GoString(C.dlerror()))
+	}
+
+	np := &nativePorcupineType{
+		lib:                           lib,
+		pv_porcupine_init_ptr:         dlsym(lib, "pv_porcupine_init"),
+		pv_porcupine_process_ptr:      dlsym(lib, "pv_porcupine_process"),
+		pv_sample_rate_ptr:            dlsym(lib, "pv_sample_rate"),
+		pv_porcupine_version_ptr:      dlsym(lib, "pv_porcupine_version"),
+		pv_porcupine_frame_length_ptr: dlsym(lib, "pv_porcupine_frame_length"),
+		pv_porcupine_delete_ptr:       dlsym(lib, "pv_porcupine_delete"),
+		pv_get_error_stack_ptr:        dlsym(lib, "pv_get_error_stack"),
+		pv_free_error_stack_ptr:       dlsym(lib, "pv_free_error_stack"),
+	}
+
+	// the error stack functions are optional and checked before each use
+	required := []struct {
+		symbol string
+		ptr    unsafe.Pointer
+	}{
+		{"pv_porcupine_init", np.pv_porcupine_init_ptr},
+		{"pv_porcupine_process", np.pv_porcupine_process_ptr},
+		{"pv_sample_rate", np.pv_sample_rate_ptr},
+		{"pv_porcupine_version", np.pv_porcupine_version_ptr},
+		{"pv_porcupine_frame_length", np.pv_porcupine_frame_length_ptr},
+		{"pv_porcupine_delete", np.pv_porcupine_delete_ptr},
+	}
+	for _, r := range required {
+		if r.ptr == nil {
+			C.dlclose(lib)
+			return nil, &MissingSymbolError{Name: r.symbol, LibraryPath: libPath}
+		}
+	}
+	return np, nil
+}
+
+func dlopenMode(flags LibraryLoadFlags) C.int {
+	mode := C.int(C.RTLD_NOW)
+	if flags&LOAD_LAZY != 0 {
+		mode = C.RTLD_LAZY
+	}
+	if flags&LOAD_GLOBAL != 0 {
+		mode |= C.RTLD_GLOBAL
+	}
+	return mode
+}
+
+func dlsym(lib unsafe.Pointer, symbol string) unsafe.Pointer {
+	symbolC := C.CString(symbol)
+	defer C.free(unsafe.Pointer(symbolC))
+	return C.dlsym(lib, symbolC)
+}
+
+func (np *nativePorcupineType) nativeInit(porcupine *Porcupine) (status PvStatus) {
 	var (
-		modelPathC  = C.CString(porcupine.ModelPath)
-		numKeywords = len(porcupine.KeywordPaths)
+		modelPathC  = C.CString(porcupine.modelPath)
+		numKeywords = len(porcupine.keywordPaths)
 		keywordsC   = make([]*C.char, numKeywords)
-		ptrC        = make([]unsafe.Pointer, 1)
+		handleC     unsafe.Pointer
 	)
 	defer C.free(unsafe.Pointer(modelPathC))
 
-	for i, s := range porcupine.KeywordPaths {
+	for i, s := range porcupine.keywordPaths {
 		keywordsC[i] = C.CString(s)
 		defer C.free(unsafe.Pointer(keywordsC[i]))
 	}
 
-	var ret = C.pv_porcupine_init_wrapper(pv_porcupine_init_ptr,
-		modelPathC,
-		(C.int32_t)(numKeywords),
-		(**C.char)(unsafe.Pointer(&keywordsC[0])),
-		(*C.float)(unsafe.Pointer(&porcupine.Sensitivities[0])),
-		&ptrC[0])
+	var ret C.int32_t
+	if requiresAccessKey(np.nativeVersion()) {
+		accessKeyC := C.CString(porcupine.AccessKey)
+		defer C.free(unsafe.Pointer(accessKeyC))
+
+		ret = C.pv_porcupine_init_with_access_key_wrapper(np.pv_porcupine_init_ptr,
+			accessKeyC,
+			modelPathC,
+			(C.int32_t)(numKeywords),
+			(**C.char)(unsafe.Pointer(&keywordsC[0])),
+			(*C.float)(unsafe.Pointer(&porcupine.sensitivities[0])),
+			&handleC)
+	} else {
+		ret = C.pv_porcupine_init_wrapper(np.pv_porcupine_init_ptr,
+			modelPathC,
+			(C.int32_t)(numKeywords),
+			(**C.char)(unsafe.Pointer(&keywordsC[0])),
+			(*C.float)(unsafe.Pointer(&porcupine.sensitivities[0])),
+			&handleC)
+	}
 
-	porcupine.handle = uintptr(ptrC[0])
+	porcupine.handle = handleC
 	return PvStatus(ret)
 }
 
-func (np nativePorcupineType) nativeDelete(porcupine *Porcupine) {
-	C.pv_porcupine_delete_wrapper(pv_porcupine_delete_ptr,
-		unsafe.Pointer(porcupine.handle))
+func (np *nativePorcupineType) nativeDelete(porcupine *Porcupine) {
+	C.pv_porcupine_delete_wrapper(np.pv_porcupine_delete_ptr,
+		porcupine.handle)
 }
 
-func (np nativePorcupineType) nativeProcess(porcupine *Porcupine, pcm []int16) (status PvStatus, keywordIndex int) {
+func (np *nativePorcupineType) nativeProcess(porcupine *Porcupine, pcm []int16) (status PvStatus, keywordIndex int) {
 
-	var index int32
-	var ret = C.pv_porcupine_process_wrapper(pv_porcupine_process_ptr,
-		unsafe.Pointer(porcupine.handle),
+	var ret = C.pv_porcupine_process_wrapper(np.pv_porcupine_process_ptr,
+		porcupine.handle,
 		(*C.int16_t)(unsafe.Pointer(&pcm[0])),
-		(*C.int32_t)(unsafe.Pointer(&index)))
-	return PvStatus(ret), int(index)
+		(*C.int32_t)(unsafe.Pointer(&porcupine.nativeKeywordIndex)))
+	return PvStatus(ret), int(porcupine.nativeKeywordIndex)
+}
+
+func (np *nativePorcupineType) nativeSampleRate() (sampleRate int) {
+	return int(C.pv_porcupine_sample_rate_wrapper(np.pv_sample_rate_ptr))
 }
 
-func (np nativePorcupineType) nativeSampleRate() (sampleRate int) {
-	return int(C.pv_porcupine_sample_rate_wrapper(pv_sample_rate_ptr))
+func (np *nativePorcupineType) nativeFrameLength() (frameLength int) {
+	return int(C.pv_porcupine_frame_length_wrapper(np.pv_porcupine_frame_length_ptr))
 }
 
-func (np nativePorcupineType) nativeFrameLength() (frameLength int) {
-	return int(C.pv_porcupine_frame_length_wrapper(pv_porcupine_frame_length_ptr))
+func (np *nativePorcupineType) nativeVersion() (version string) {
+	return C.GoString(C.pv_porcupine_version_wrapper(np.pv_porcupine_version_ptr))
 }
 
-func (np nativePorcupineType) nativeVersion() (version string) {
-	return C.GoString(C.pv_porcupine_version_wrapper(pv_porcupine_version_ptr))
+func (np *nativePorcupineType) nativeErrorStack() (messages []string) {
+	if np.pv_get_error_stack_ptr == nil || np.pv_free_error_stack_ptr == nil {
+		return nil
+	}
+
+	var messageStack **C.char
+	var messageStackDepth C.int32_t
+	ret := C.pv_get_error_stack_wrapper(np.pv_get_error_stack_ptr, &messageStack, &messageStackDepth)
+	if PvStatus(ret) != SUCCESS || messageStack == nil {
+		return nil
+	}
+	defer C.pv_free_error_stack_wrapper(np.pv_free_error_stack_ptr, messageStack)
+
+	stack := (*[1 << 20]*C.char)(unsafe.Pointer(messageStack))[:messageStackDepth:messageStackDepth]
+	for _, message := range stack {
+		messages = append(messages, C.GoString(message))
+	}
+	return messages
 }
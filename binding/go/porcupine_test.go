@@ -11,14 +11,32 @@
 package porcupine
 
 import (
+	"bytes"
+	"context"
 	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"log"
 	"math"
+	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
+	"time"
+	"unsafe"
 )
 
 func TestProcess(t *testing.T) {
+	requireNativeLibrary(t)
 
 	test_file, _ := filepath.Abs("../../resources/audio_samples/porcupine.wav")
 
@@ -28,40 +46,19 @@ func TestProcess(t *testing.T) {
 		t.Fatalf("%v", err)
 	}
 
-	t.Logf("Porcupine Version: %s", Version)
-	t.Logf("Frame Length: %d", FrameLength)
-	t.Logf("Sample Rate: %d", SampleRate)
+	t.Logf("Porcupine Version: %s", Version())
+	t.Logf("Frame Length: %d", FrameLength())
+	t.Logf("Sample Rate: %d", SampleRate())
 
-	data, err := ioutil.ReadFile(test_file)
+	detections, err := p.ProcessFile(context.Background(), test_file)
 	if err != nil {
-		t.Fatalf("Could not read test file: %v", err)
+		t.Fatalf("Could not process test file: %v", err)
 	}
-	data = data[44:] // skip header
-
-	frameLenBytes := FrameLength * 2
-	frameCount := int(math.Floor(float64(len(data)) / float64(frameLenBytes)))
-	sampleBuffer := make([]int16, FrameLength)
-	var results []int
-	for i := 0; i < frameCount; i++ {
-		start := i * frameLenBytes
-
-		for j := 0; j < FrameLength; j++ {
-			dataOffset := start + (j * 2)
-			sampleBuffer[j] = int16(binary.LittleEndian.Uint16(data[dataOffset : dataOffset+2]))
-		}
-
-		result, err := p.Process(sampleBuffer)
-		if err != nil {
-			t.Fatalf("Could not read test file: %v", err)
-		}
-
-		if result == 0 {
-			results = append(results, result)
-			t.Logf("Keyword triggered at %f", float64((i+1)*FrameLength)/float64(SampleRate))
-		}
+	for _, detection := range detections {
+		t.Logf("Keyword triggered at %v", detection.Timestamp)
 	}
 
-	if len(results) != 1 || results[0] != 0 {
+	if len(detections) != 1 || detections[0].Index != 0 {
 		t.Fatalf("Failed to find keyword '%s.'", p.BuiltInKeywords[0])
 	}
 
@@ -72,6 +69,7 @@ func TestProcess(t *testing.T) {
 }
 
 func TestMultiple(t *testing.T) {
+	requireNativeLibrary(t)
 
 	test_file, _ := filepath.Abs("../../resources/audio_samples/multiple_keywords.wav")
 
@@ -86,47 +84,3313 @@ func TestMultiple(t *testing.T) {
 		t.Fatalf("%v", err)
 	}
 
-	t.Logf("Porcupine Version: %s", Version)
-	t.Logf("Frame Length: %d", FrameLength)
-	t.Logf("Samples Rate: %d", SampleRate)
+	t.Logf("Porcupine Version: %s", Version())
+	t.Logf("Frame Length: %d", FrameLength())
+	t.Logf("Samples Rate: %d", SampleRate())
+
+	detections, err := p.ProcessFile(context.Background(), test_file)
+	if err != nil {
+		t.Fatalf("Could not process test file: %v", err)
+	}
+
+	if len(detections) != len(expectedResults) {
+		t.Fatalf("Expected %d detections, but got %d", len(expectedResults), len(detections))
+	}
+	for i, detection := range detections {
+		t.Logf("Keyword %d triggered at %v", detection.Index, detection.Timestamp)
+		detectedKeyword := p.BuiltInKeywords[detection.Index]
+		if detectedKeyword != expectedResults[i] {
+			t.Fatalf("Expected keyword %s, but %s was detected.", expectedResults[i], detectedKeyword)
+		}
+	}
+
+	delErr := p.Delete()
+	if delErr != nil {
+		t.Fatalf("%v", delErr)
+	}
+}
+
+func TestPostRollRange(t *testing.T) {
+	requireNativeLibrary(t)
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/porcupine.wav")
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	err := p.Init()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	pcm := readTestAudio(t, test_file)
+
+	detectionOffset := -1
+	frameCount := len(pcm) / FrameLength()
+	for i := 0; i < frameCount; i++ {
+		result, err := p.Process(pcm[i*FrameLength() : (i+1)*FrameLength()])
+		if err != nil {
+			t.Fatalf("Failed to process frame: %v", err)
+		}
+		if result >= 0 {
+			detectionOffset = (i + 1) * FrameLength()
+			break
+		}
+	}
+	if detectionOffset < 0 {
+		t.Fatalf("Failed to find keyword '%s.'", p.BuiltInKeywords[0])
+	}
+
+	postRoll := 500 * time.Millisecond
+	start, end := PostRollRange(detectionOffset, postRoll, len(pcm))
+	if start != detectionOffset {
+		t.Fatalf("Expected range to start at detection offset %d, but got %d", detectionOffset, start)
+	}
+	expectedEnd := detectionOffset + SampleRate()/2
+	if expectedEnd > len(pcm) {
+		expectedEnd = len(pcm)
+	}
+	if end != expectedEnd {
+		t.Fatalf("Expected range to end at %d, but got %d", expectedEnd, end)
+	}
+
+	for _, postRoll := range []time.Duration{time.Hour, math.MaxInt64} {
+		if _, end = PostRollRange(detectionOffset, postRoll, len(pcm)); end != len(pcm) {
+			t.Fatalf("Expected a post-roll of %v to be clamped to buffer length %d, but got %d", postRoll,
+				len(pcm), end)
+		}
+	}
+}
+
+func TestFrameToSampleRange(t *testing.T) {
+	native := &testNative{version: "1.9.0", processResults: []int{-1, -1, -1, 0}}
+	p := Porcupine{LibraryPath: registerTestNative(t, native), BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	var detection *Detection
+	for detection == nil {
+		var err error
+		if detection, err = p.ProcessDetailed(make([]int16, FrameLength())); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+
+	start, end := FrameToSampleRange(int(detection.FrameIndex))
+	if start != 3*FrameLength() || end != 4*FrameLength() {
+		t.Fatalf("Expected frame 3 to span [%d, %d), but got [%d, %d)", 3*FrameLength(), 4*FrameLength(), start, end)
+	}
+	if int64(end) != detection.SampleOffset {
+		t.Fatalf("Expected the frame to end at the detection's sample offset %d, but got %d", detection.SampleOffset, end)
+	}
+	if SampleToTime(end) != detection.Timestamp {
+		t.Fatalf("Expected the end of the frame at %v, the detection's timestamp, but got %v",
+			detection.Timestamp, SampleToTime(end))
+	}
+
+	if SampleToTime(SampleRate()/4) != 250*time.Millisecond || SampleToTime(0) != 0 {
+		t.Fatalf("Expected samples to map to times at %d Hz, but got %v and %v",
+			SampleRate(), SampleToTime(SampleRate()/4), SampleToTime(0))
+	}
+
+	week := 7 * 24 * time.Hour
+	if got := SampleToTime(int(week/time.Second) * SampleRate()); got != week {
+		t.Fatalf("Expected a week of samples to map to %v, but got %v", week, got)
+	}
+}
+
+// Skips tests that rely on detections in real audio when built with the fake native library.
+func requireNativeLibrary(t *testing.T) {
+	if usingFakeNative {
+		t.Skip("requires the native library")
+	}
+}
+
+// Returns the path of the native library loaded by default, which is only extracted from the embedded files if
+// PORCUPINE_LIBRARY_PATH is not set.
+func defaultLibraryFile() string {
+	getDefaultLibrary()
+	if defaultLibraryPath != "" {
+		return defaultLibraryPath
+	}
+	return libName
+}
+
+// Returns the path of the extracted English model, extracting it if an earlier test changed the extraction
+// directory.
+func testModelFile(t *testing.T) string {
+	if err := loadPorcupine(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	return defaultModelFile
+}
+
+// Returns the path of an extracted built-in keyword file.
+func testKeywordFile(t *testing.T, keyword BuiltInKeyword) string {
+	if err := loadPorcupine(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	return builtinKeywords[string(keyword)]
+}
 
-	data, err := ioutil.ReadFile(test_file)
+func readTestAudio(t *testing.T, path string) []int16 {
+	pcm, err := ReadWAVFile(path)
 	if err != nil {
 		t.Fatalf("Could not read test file: %v", err)
 	}
-	data = data[44:] // skip header
+	return pcm
+}
 
-	var results []int
-	frameLenBytes := FrameLength * 2
-	frameCount := int(math.Floor(float64(len(data)) / float64(frameLenBytes)))
-	sampleBuffer := make([]int16, FrameLength)
+func TestProcessFloat32(t *testing.T) {
+	requireNativeLibrary(t)
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/porcupine.wav")
+	pcm := readTestAudio(t, test_file)
+
+	pInt16 := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	pFloat32 := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	for _, p := range []*Porcupine{&pInt16, &pFloat32} {
+		if err := p.Init(); err != nil {
+			t.Fatalf("%v", err)
+		}
+		defer p.Delete()
+	}
+
+	frameLength := FrameLength()
+	floatFrame := make([]float32, frameLength)
+	detections := 0
+	for i := 0; i < len(pcm)/frameLength; i++ {
+		frame := pcm[i*frameLength : (i+1)*frameLength]
+		for j, sample := range frame {
+			floatFrame[j] = float32(sample) / math.MaxInt16
+		}
+
+		converted, _ := float32ToInt16(floatFrame)
+		if !reflect.DeepEqual(converted, frame) {
+			t.Fatalf("Expected float samples to convert back to the original frame %d.", i)
+		}
+
+		int16Result, err := pInt16.Process(frame)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		float32Result, err := pFloat32.ProcessFloat32(floatFrame)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if int16Result != float32Result {
+			t.Fatalf("Expected the same result for frame %d, but got %d for int16 and %d for float32 input",
+				i, int16Result, float32Result)
+		}
+		if int16Result >= 0 {
+			detections++
+		}
+	}
+	if detections != 1 {
+		t.Fatalf("Expected a single detection, but got %d", detections)
+	}
+
+	clipped, _ := float32ToInt16([]float32{1.5, -1.5, 1, -1, 0.5})
+	if !reflect.DeepEqual(clipped, []int16{32767, -32768, 32767, -32767, 16384}) {
+		t.Fatalf("Unexpected conversion of out-of-range samples: %v", clipped)
+	}
+}
+
+func TestProcessFloat32NonFinite(t *testing.T) {
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	err := p.Init()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	frame := make([]float32, FrameLength())
+	frame[0] = float32(math.NaN())
+	frame[1] = float32(math.Inf(1))
+	frame[2] = float32(math.Inf(-1))
+
+	_, err = p.ProcessFloat32(frame)
+	if err != nil {
+		t.Fatalf("Expected non-finite samples to be sanitized, but got error: %v", err)
+	}
+	if p.Stats().NonFiniteSamples != 3 {
+		t.Fatalf("Expected 3 sanitized samples, but got %d", p.Stats().NonFiniteSamples)
+	}
+
+	p.NonFinitePolicy = REJECT_NON_FINITE
+	_, err = p.ProcessFloat32(frame)
+	if err == nil {
+		t.Fatalf("Expected an error for non-finite samples.")
+	}
+	if p.Stats().NonFiniteSamples != 3 {
+		t.Fatalf("Expected rejected frame to leave counter at 3, but got %d", p.Stats().NonFiniteSamples)
+	}
+
+	pcm, nonFinite := float32ToInt16(frame[:4])
+	expected := []int16{0, math.MaxInt16, math.MinInt16, 0}
+	for i := range expected {
+		if pcm[i] != expected[i] {
+			t.Fatalf("Expected sample %d to convert to %d, but got %d", i, expected[i], pcm[i])
+		}
+	}
+	if nonFinite != 3 {
+		t.Fatalf("Expected 3 non-finite samples, but got %d", nonFinite)
+	}
+}
+
+func TestRecentDetections(t *testing.T) {
+	requireNativeLibrary(t)
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/multiple_keywords.wav")
+
+	p := Porcupine{
+		BuiltInKeywords: []BuiltInKeyword{
+			ALEXA, AMERICANO, BLUEBERRY, BUMBLEBEE,
+			GRAPEFRUIT, GRASSHOPPER, PICOVOICE, PORCUPINE,
+			TERMINATOR},
+		DetectionHistorySize: 4}
+	expectedResults := []BuiltInKeyword{GRASSHOPPER, PICOVOICE, PORCUPINE, TERMINATOR}
+
+	err := p.Init()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	pcm := readTestAudio(t, test_file)
+	frameCount := len(pcm) / FrameLength()
 	for i := 0; i < frameCount; i++ {
-		start := i * frameLenBytes
+		_, err := p.Process(pcm[i*FrameLength() : (i+1)*FrameLength()])
+		if err != nil {
+			t.Fatalf("Failed to process frame: %v", err)
+		}
+	}
 
-		for j := 0; j < FrameLength; j++ {
-			dataOffset := start + (j * 2)
-			sampleBuffer[j] = int16(binary.LittleEndian.Uint16(data[dataOffset : dataOffset+2]))
+	detections := p.RecentDetections()
+	if len(detections) != len(expectedResults) {
+		t.Fatalf("Expected %d recent detections, but got %d", len(expectedResults), len(detections))
+	}
+	for i := range detections {
+		if detections[i].Keyword != string(expectedResults[i]) {
+			t.Fatalf("Expected keyword %s, but %s was retained.", expectedResults[i], detections[i].Keyword)
+		}
+		if i > 0 && detections[i].FrameIndex <= detections[i-1].FrameIndex {
+			t.Fatalf("Expected recent detections to be ordered oldest first.")
 		}
+	}
+
+	detections[0].Keyword = "modified"
+	if p.RecentDetections()[0].Keyword == "modified" {
+		t.Fatalf("Expected RecentDetections to return a copy.")
+	}
+}
+
+func TestSaveLoadState(t *testing.T) {
+	requireNativeLibrary(t)
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/porcupine.wav")
+
+	p := Porcupine{
+		BuiltInKeywords: []BuiltInKeyword{PORCUPINE},
+		Sensitivities:   []float32{0.7}}
+	err := p.Init()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	var buf bytes.Buffer
+	err = p.SaveState(&buf)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	restored, err := LoadState(&buf)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer restored.Delete()
+
+	if restored.ModelPath != p.ModelPath ||
+		!reflect.DeepEqual(restored.BuiltInKeywords, p.BuiltInKeywords) ||
+		!reflect.DeepEqual(restored.KeywordPaths, p.KeywordPaths) ||
+		!reflect.DeepEqual(restored.Sensitivities, p.Sensitivities) {
+		t.Fatalf("Restored configuration %+v does not match original %+v", restored, &p)
+	}
 
-		result, err := p.Process(sampleBuffer)
+	pcm := readTestAudio(t, test_file)
+	var results []int
+	for i := 0; i < len(pcm)/FrameLength(); i++ {
+		result, err := restored.Process(pcm[i*FrameLength() : (i+1)*FrameLength()])
 		if err != nil {
-			t.Fatalf("Could not read test file: %v", err)
+			t.Fatalf("Failed to process frame: %v", err)
 		}
 		if result >= 0 {
-			t.Logf("Keyword %d triggered at %f", result, float64((i+1)*FrameLength)/float64(SampleRate))
 			results = append(results, result)
 		}
 	}
+	if len(results) != 1 || results[0] != 0 {
+		t.Fatalf("Failed to find keyword '%s' with restored instance.", p.BuiltInKeywords[0])
+	}
+}
+
+func TestLoadStateOptions(t *testing.T) {
+	native := &testNative{version: "2.0.0"}
+
+	// the embedded files are for 1.9, so use files that stand in for ones built for 2.0
+	p := Porcupine{LibraryPath: registerTestNative(t, native), AccessKey: "secret",
+		ModelPath:    copyTestFile(t, testModelFile(t)),
+		KeywordPaths: []string{copyTestFile(t, testKeywordFile(t, PORCUPINE))}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
 
-	for i := range results {
-		detectedKeyword := p.BuiltInKeywords[results[i]]
-		if detectedKeyword != expectedResults[i] {
-			t.Fatalf("Expected keyword %s, but %s was detected.", expectedResults[i], detectedKeyword)
+	var buf bytes.Buffer
+	if err := p.SaveState(&buf); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if strings.Contains(buf.String(), "secret") {
+		t.Fatalf("Expected the AccessKey not to be saved, but got %s", buf.String())
+	}
+
+	if restored, err := LoadState(bytes.NewReader(buf.Bytes())); err == nil {
+		restored.Delete()
+		t.Fatalf("Expected LoadState to fail without an AccessKey.")
+	}
+
+	restored, err := LoadState(bytes.NewReader(buf.Bytes()), WithAccessKey("secret"), WithMinDetectionGap(time.Second))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer restored.Delete()
+	if native.accessKey != "secret" || restored.MinDetectionGap != time.Second {
+		t.Fatalf("Expected the options to be applied after the saved configuration, but got AccessKey '%s' and "+
+			"MinDetectionGap %v", native.accessKey, restored.MinDetectionGap)
+	}
+}
+
+func TestSaveLoadStateKeywordSet(t *testing.T) {
+	requireNativeLibrary(t)
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/multiple_keywords.wav")
+
+	p := Porcupine{
+		KeywordSet:          NewKeywordSet().AddBuiltIn(ALEXA, 0.6).AddCustom(testKeywordFile(t, PORCUPINE), 0.5),
+		StrictSensitivities: true,
+		LibraryPath:         defaultLibraryFile(),
+		MinDetectionGap:     time.Second}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	var buf bytes.Buffer
+	if err := p.SaveState(&buf); err != nil {
+		t.Fatalf("%v", err)
+	}
+	t.Logf("%s", buf.String())
+
+	restored, err := LoadState(&buf)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer restored.Delete()
+
+	if !reflect.DeepEqual(restored.KeywordSet, p.KeywordSet) || !restored.StrictSensitivities ||
+		restored.LibraryPath != p.LibraryPath || restored.MinDetectionGap != p.MinDetectionGap {
+		t.Fatalf("Restored configuration %+v does not match original %+v", restored, &p)
+	}
+	if !reflect.DeepEqual(restored.Keywords(), p.Keywords()) {
+		t.Fatalf("Expected keywords %v, but got %v", p.Keywords(), restored.Keywords())
+	}
+
+	pcm := readTestAudio(t, test_file)
+	var results []int
+	for i := 0; i < len(pcm)/FrameLength(); i++ {
+		result, err := restored.Process(pcm[i*FrameLength() : (i+1)*FrameLength()])
+		if err != nil {
+			t.Fatalf("Failed to process frame: %v", err)
 		}
+		if result >= 0 {
+			results = append(results, result)
+		}
+	}
+	if !reflect.DeepEqual(results, []int{1, 0, 1}) {
+		t.Fatalf("Expected detections [1 0 1] with restored instance, but got %v", results)
 	}
+}
 
-	delErr := p.Delete()
-	if delErr != nil {
-		t.Fatalf("%v", delErr)
+func TestEngineConfigJSON(t *testing.T) {
+	config := EngineConfig{
+		ModelPath:                testModelFile(t),
+		BuiltInKeywords:          []BuiltInKeyword{PORCUPINE, HEY_GOOGLE},
+		KeywordPaths:             []string{testKeywordFile(t, BUMBLEBEE)},
+		BuiltInSensitivities:     map[BuiltInKeyword]float32{HEY_GOOGLE: 0.8},
+		KeywordPathSensitivities: map[string]float32{testKeywordFile(t, BUMBLEBEE): 0.2},
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	var decoded EngineConfig
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if !reflect.DeepEqual(decoded, config) {
+		t.Fatalf("Expected %+v after a round trip through %s, but got %+v", config, data, decoded)
+	}
+	if !strings.Contains(string(data), `"builtin_keywords":["porcupine","hey google"]`) {
+		t.Fatalf("Expected built-in keywords to be encoded as their names, but got %s", data)
+	}
+
+	p, err := NewPorcupine(WithEngineConfig(decoded))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+	if !reflect.DeepEqual(p.sensitivities, []float32{0.2, 0.5, 0.8}) {
+		t.Fatalf("Expected sensitivities [0.2 0.5 0.8], but got %v", p.sensitivities)
+	}
+
+	invalid := []string{
+		`{"builtin_keywords": ["porcupin"]}`,
+		`{"builtin_keywords": [1]}`,
+		`{"keyword_set": [{"sensitivity": 0.5}]}`,
+		`{"keyword_set": [{"builtin": "porcupin", "sensitivity": 0.5}]}`,
+	}
+	for _, data := range invalid {
+		var config EngineConfig
+		if err := json.Unmarshal([]byte(data), &config); err == nil {
+			t.Fatalf("Expected an error decoding %s", data)
+		} else {
+			t.Logf("%v", err)
+		}
+	}
+}
+
+func TestUnsupportedPlatform(t *testing.T) {
+
+	defaultPlatformDetector := platformDetector
+	platformDetector = func() (string, string) {
+		return "plan9", "mips"
+	}
+	defer func() { platformDetector = defaultPlatformDetector }()
+
+	var platformErr *UnsupportedPlatformError
+	if _, err := getOS(); !errors.As(err, &platformErr) {
+		t.Fatalf("Expected UnsupportedPlatformError from getOS, but got %v", err)
+	}
+	if platformErr.OS != "plan9" || platformErr.Arch != "mips" {
+		t.Fatalf("Expected error for plan9/mips, but got %s/%s", platformErr.OS, platformErr.Arch)
+	}
+
+	if _, err := extractLib(); !errors.As(err, &platformErr) {
+		t.Fatalf("Expected UnsupportedPlatformError from extractLib, but got %v", err)
+	}
+	t.Logf("%v", platformErr)
+}
+
+func TestPlatformSelection(t *testing.T) {
+
+	defaultPlatformDetector := platformDetector
+	defer func() { platformDetector = defaultPlatformDetector }()
+
+	// an empty library marks a platform that is not supported
+	tests := []struct {
+		goos     string
+		goarch   string
+		library  string
+		platform string
+	}{
+		{"darwin", "amd64", "embedded/lib/mac/x86_64/libpv_porcupine.dylib", "mac"},
+		{"darwin", "arm64", "", ""},
+		{"linux", "amd64", "embedded/lib/linux/x86_64/libpv_porcupine.so", "linux"},
+		{"linux", "386", "", ""},
+		{"windows", "amd64", "embedded/lib/windows/amd64/libpv_porcupine.dll", "windows"},
+		{"windows", "386", "", ""},
+		{"windows", "arm64", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.goos+"/"+tt.goarch, func(t *testing.T) {
+			platformDetector = func() (string, string) { return tt.goos, tt.goarch }
+
+			libPath, err := embeddedLibraryFile()
+			if tt.library == "" {
+				var platformErr *UnsupportedPlatformError
+				if !errors.As(err, &platformErr) {
+					t.Fatalf("Expected UnsupportedPlatformError, but got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			if libPath != tt.library {
+				t.Fatalf("Expected library %s, but got %s", tt.library, libPath)
+			}
+			if _, err := embeddedFS.ReadFile(libPath); embeddedAssets && err != nil {
+				t.Fatalf("Expected library %s to be embedded, but got: %v", libPath, err)
+			}
+			if platform, err := getOS(); err != nil || platform != tt.platform {
+				t.Fatalf("Expected keyword files for %s, but got '%s' (%v)", tt.platform, platform, err)
+			}
+		})
+	}
+}
+
+func TestBuiltInKeywordFiles(t *testing.T) {
+
+	for _, platform := range []string{"linux", "mac", "windows", "raspberry-pi"} {
+		keywordFiles, err := embeddedKeywordFiles(ENGLISH, platform)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		seenPaths := make(map[string]BuiltInKeyword)
+		for _, keyword := range BuiltInKeywords {
+			keywordPath, ok := keywordFiles[string(keyword)]
+			if !ok {
+				t.Fatalf("No keyword file found for '%s' on %s", keyword, platform)
+			}
+
+			expectedFile := fmt.Sprintf("%s_%s.ppn", keyword, platform)
+			if filepath.Base(keywordPath) != expectedFile {
+				t.Fatalf("Expected '%s' to map to %s on %s, but got %s", keyword, expectedFile, platform, keywordPath)
+			}
+
+			if other, ok := seenPaths[keywordPath]; ok {
+				t.Fatalf("'%s' and '%s' map to the same keyword file on %s", keyword, other, platform)
+			}
+			seenPaths[keywordPath] = keyword
+		}
+	}
+
+	if keywordNameFromFile("hey_there_linux.ppn", "linux") != "hey_there" {
+		t.Fatalf("Expected keyword names containing underscores to be preserved.")
+	}
+}
+
+func TestProcessIntoAllocations(t *testing.T) {
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	err := p.Init()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	scratch := make([]int16, FrameLength())
+	floatFrame := make([]float32, FrameLength())
+	byteFrame := make([]byte, FrameLength()*2)
+
+	if _, err := p.ProcessFloat32Into(floatFrame, make([]int16, FrameLength()-1)); err == nil {
+		t.Fatalf("Expected an error for a scratch buffer of the wrong size.")
+	}
+	if _, err := p.ProcessBytesInto(byteFrame[1:], scratch); err == nil {
+		t.Fatalf("Expected an error for a byte frame of the wrong size.")
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		p.Process(scratch)
+	})
+	if allocs != 0 {
+		t.Fatalf("Expected Process to not allocate, but got %f allocations per call", allocs)
+	}
+
+	allocs = testing.AllocsPerRun(100, func() {
+		p.ProcessFloat32Into(floatFrame, scratch)
+	})
+	if allocs != 0 {
+		t.Fatalf("Expected ProcessFloat32Into to not allocate, but got %f allocations per call", allocs)
+	}
+
+	allocs = testing.AllocsPerRun(100, func() {
+		p.ProcessBytesInto(byteFrame, scratch)
+	})
+	if allocs != 0 {
+		t.Fatalf("Expected ProcessBytesInto to not allocate, but got %f allocations per call", allocs)
+	}
+}
+
+func BenchmarkProcess(b *testing.B) {
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	err := p.Init()
+	if err != nil {
+		b.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	frame := make([]int16, FrameLength())
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.Process(frame)
+	}
+}
+
+func TestInitWithRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		attempts     int
+		initStatuses []PvStatus
+		initStatus   PvStatus
+		expected     PvStatus
+		remaining    int
+	}{
+		{"transient failures then success", 3, []PvStatus{ACTIVATION_THROTTLED, IO_ERROR}, SUCCESS, SUCCESS, 0},
+		{"permanent failure", 3, []PvStatus{INVALID_ARGUMENT, SUCCESS}, SUCCESS, INVALID_ARGUMENT, 1},
+		{"refused access key", 3, []PvStatus{ACTIVATION_REFUSED, SUCCESS}, SUCCESS, ACTIVATION_REFUSED, 1},
+		{"attempts exhausted", 2, nil, ACTIVATION_THROTTLED, ACTIVATION_THROTTLED, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			native := &testNative{
+				version:      "1.9.0",
+				initStatus:   tt.initStatus,
+				initStatuses: append([]PvStatus(nil), tt.initStatuses...),
+			}
+			p := Porcupine{LibraryPath: registerTestNative(t, native), BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+
+			err := p.InitWithRetry(tt.attempts, time.Millisecond)
+			defer p.Delete()
+			if tt.expected == SUCCESS {
+				if err != nil {
+					t.Fatalf("Expected Init to succeed after retrying, but got %v", err)
+				}
+			} else {
+				var porcupineErr *PorcupineError
+				if !errors.As(err, &porcupineErr) || porcupineErr.StatusCode != tt.expected {
+					t.Fatalf("Expected an error with status %s, but got %v", pvStatusToString(tt.expected), err)
+				}
+			}
+			if len(native.initStatuses) != tt.remaining {
+				t.Fatalf("Expected %d init results to be left unused, but got %d", tt.remaining, len(native.initStatuses))
+			}
+		})
+	}
+
+	var p Porcupine
+	if err := p.InitWithRetry(0, 0); err == nil {
+		t.Fatalf("Expected an error for 0 attempts.")
+	}
+}
+
+func TestBenchmarkFrame(t *testing.T) {
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	if _, err := BenchmarkFrame(&p, 10); err == nil {
+		t.Fatalf("Expected an error benchmarking an uninitialized instance.")
+	}
+	if _, err := BenchmarkFrame(nil, 10); err == nil {
+		t.Fatalf("Expected an error benchmarking a nil instance.")
+	}
+
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	if _, err := BenchmarkFrame(&p, -1); err == nil {
+		t.Fatalf("Expected an error for a negative number of frames.")
+	}
+	elapsed, err := BenchmarkFrame(&p, 10)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if elapsed <= 0 || p.frameCount != 10 {
+		t.Fatalf("Expected 10 frames to be processed in a positive time, but got %d frames in %v", p.frameCount, elapsed)
+	}
+	t.Logf("%v per frame", elapsed/10)
+}
+
+func BenchmarkProcessUnchecked(b *testing.B) {
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	err := p.Init()
+	if err != nil {
+		b.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	frame := make([]int16, FrameLength())
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.ProcessUnchecked(frame)
+	}
+}
+
+func TestProcessUnchecked(t *testing.T) {
+	var uninitialized Porcupine
+	if _, err := uninitialized.ProcessUnchecked(make([]int16, 512)); err == nil {
+		t.Fatalf("Expected an error processing with an uninitialized instance.")
+	}
+
+	native := &testNative{version: "1.9.0", processResults: []int{-1, 0}}
+	p := Porcupine{LibraryPath: registerTestNative(t, native), BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	if _, err := p.ProcessUnchecked(nil); err == nil {
+		t.Fatalf("Expected an error processing an empty frame.")
+	}
+
+	var results []int
+	for i := 0; i < 2; i++ {
+		keywordIndex, err := p.ProcessUnchecked(make([]int16, FrameLength()))
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		results = append(results, keywordIndex)
+	}
+	if !reflect.DeepEqual(results, []int{-1, 0}) || p.frameCount != 2 || len(p.RecentDetections()) != 1 {
+		t.Fatalf("Expected results [-1 0] with 2 frames and 1 detection, but got %v with %d frames and %d detections",
+			results, p.frameCount, len(p.RecentDetections()))
+	}
+}
+
+func BenchmarkProcessFloat32Into(b *testing.B) {
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	err := p.Init()
+	if err != nil {
+		b.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	scratch := make([]int16, FrameLength())
+	frame := make([]float32, FrameLength())
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.ProcessFloat32Into(frame, scratch)
+	}
+}
+
+func BenchmarkProcessBytesInto(b *testing.B) {
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	err := p.Init()
+	if err != nil {
+		b.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	scratch := make([]int16, FrameLength())
+	frame := make([]byte, FrameLength()*2)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.ProcessBytesInto(frame, scratch)
+	}
+}
+
+func TestProcessWithScores(t *testing.T) {
+	requireNativeLibrary(t)
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/porcupine.wav")
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	err := p.Init()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	pcm := readTestAudio(t, test_file)
+	var results []int
+	for i := 0; i < len(pcm)/FrameLength(); i++ {
+		result, scores, err := p.ProcessWithScores(pcm[i*FrameLength() : (i+1)*FrameLength()])
+		if err != nil {
+			t.Fatalf("Failed to process frame: %v", err)
+		}
+		if scores != nil {
+			t.Fatalf("Expected no scores from Porcupine %s, but got %v", Version(), scores)
+		}
+		if result >= 0 {
+			results = append(results, result)
+		}
+	}
+
+	if len(results) != 1 || results[0] != 0 {
+		t.Fatalf("Failed to find keyword '%s.'", p.BuiltInKeywords[0])
+	}
+}
+
+func TestProcessWithScoresFallback(t *testing.T) {
+	native := &testNative{version: "1.9.0", processResults: []int{-1, 0}}
+	p := Porcupine{LibraryPath: registerTestNative(t, native), BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	var results []int
+	for i := 0; i < 2; i++ {
+		keywordIndex, scores, err := p.ProcessWithScores(make([]int16, FrameLength()))
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if scores != nil {
+			t.Fatalf("Expected no scores from a library without them, but got %v", scores)
+		}
+		results = append(results, keywordIndex)
+	}
+	if !reflect.DeepEqual(results, []int{-1, 0}) || p.frameCount != 2 {
+		t.Fatalf("Expected results [-1 0] from 2 processed frames, but got %v from %d frames", results, p.frameCount)
+	}
+
+	if _, _, err := p.ProcessWithScores(make([]int16, FrameLength()-1)); err == nil {
+		t.Fatalf("Expected an error for a frame of the wrong size.")
+	}
+}
+
+func TestProcessAll(t *testing.T) {
+	requireNativeLibrary(t)
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/multiple_keywords.wav")
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{ALEXA, PORCUPINE}}
+	err := p.Init()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	pcm := readTestAudio(t, test_file)
+	var results []int
+	for i := 0; i < len(pcm)/FrameLength(); i++ {
+		indices, err := p.ProcessAll(pcm[i*FrameLength() : (i+1)*FrameLength()])
+		if err != nil {
+			t.Fatalf("Failed to process frame: %v", err)
+		}
+		if indices == nil || len(indices) > 1 {
+			t.Fatalf("Expected 0 or 1 detections per frame, but got %v", indices)
+		}
+		results = append(results, indices...)
+	}
+
+	if !reflect.DeepEqual(results, []int{1, 0, 1}) {
+		t.Fatalf("Expected detections [1 0 1], but got %v", results)
+	}
+}
+
+func TestProcessDetailed(t *testing.T) {
+	native := &testNative{version: "1.9.0", processResults: []int{-1, -1, 1}}
+	p := Porcupine{LibraryPath: registerTestNative(t, native), BuiltInKeywords: []BuiltInKeyword{ALEXA, PORCUPINE}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	for i := 0; i < 2; i++ {
+		if detection, err := p.ProcessDetailed(make([]int16, 512)); detection != nil || err != nil {
+			t.Fatalf("Expected no detection, but got %+v, %v", detection, err)
+		}
+	}
+	detection, err := p.ProcessDetailed(make([]int16, 512))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	expected := Detection{Index: 1, Keyword: "porcupine", FrameIndex: 2, Timestamp: 96 * time.Millisecond, SampleOffset: 1536}
+	if detection == nil || *detection != expected {
+		t.Fatalf("Expected %+v, but got %+v", expected, detection)
+	}
+
+	if _, err := p.ProcessDetailed(make([]int16, 511)); err == nil {
+		t.Fatalf("Expected an error for a frame of the wrong size.")
+	}
+}
+func TestProcessWithScore(t *testing.T) {
+	requireNativeLibrary(t)
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/multiple_keywords.wav")
+
+	p := Porcupine{
+		BuiltInKeywords: []BuiltInKeyword{ALEXA, PORCUPINE},
+		Sensitivities:   []float32{0.6, 0.4}}
+	err := p.Init()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	pcm := readTestAudio(t, test_file)
+	detections := 0
+	for i := 0; i < len(pcm)/FrameLength(); i++ {
+		result, score, err := p.ProcessWithScore(pcm[i*FrameLength() : (i+1)*FrameLength()])
+		if err != nil {
+			t.Fatalf("Failed to process frame: %v", err)
+		}
+		if result < 0 {
+			if score != 0 {
+				t.Fatalf("Expected a score of 0 without a detection, but got %v", score)
+			}
+			continue
+		}
+		detections++
+		if score != p.Sensitivities[result] {
+			t.Fatalf("Expected score %v for keyword %d, but got %v", p.Sensitivities[result], result, score)
+		}
+	}
+
+	if detections == 0 {
+		t.Fatalf("Expected at least one detection.")
+	}
+}
+
+func TestProcessWithScoreSensitivity(t *testing.T) {
+	native := &testNative{version: "1.9.0", processResults: []int{-1, 1}}
+	p := Porcupine{LibraryPath: registerTestNative(t, native), BuiltInKeywords: []BuiltInKeyword{ALEXA, PORCUPINE},
+		Sensitivities: []float32{0.6, 0.4}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	keywordIndex, score, err := p.ProcessWithScore(make([]int16, FrameLength()))
+	if err != nil || keywordIndex != -1 || score != 0 {
+		t.Fatalf("Expected no detection with a score of 0, but got %d, %v, %v", keywordIndex, score, err)
+	}
+
+	keywordIndex, score, err = p.ProcessWithScore(make([]int16, FrameLength()))
+	if err != nil || keywordIndex != 1 || score != 0.4 {
+		t.Fatalf("Expected keyword 1 with its sensitivity 0.4 as score, but got %d, %v, %v", keywordIndex, score, err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		porcupine *Porcupine
+	}{
+		{"no keywords", &Porcupine{}},
+		{"invalid built-in keyword", &Porcupine{BuiltInKeywords: []BuiltInKeyword{"not a keyword"}}},
+		{"missing model file", &Porcupine{
+			ModelPath:       "/does/not/exist.pv",
+			BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}},
+		{"missing keyword file", &Porcupine{KeywordPaths: []string{"/does/not/exist.ppn"}}},
+		{"sensitivity count mismatch", &Porcupine{
+			BuiltInKeywords: []BuiltInKeyword{PORCUPINE, ALEXA},
+			Sensitivities:   []float32{0.5}}},
+		{"sensitivity out of range", &Porcupine{
+			BuiltInKeywords: []BuiltInKeyword{PORCUPINE},
+			Sensitivities:   []float32{1.5}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.porcupine.Validate()
+			if err == nil {
+				t.Fatalf("Expected a validation error.")
+			}
+			if tt.porcupine.handle != nil {
+				t.Fatalf("Expected Validate to not create a native engine.")
+			}
+			t.Logf("%v", err)
+		})
+	}
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE, ALEXA}}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("Expected a valid configuration, but got %v", err)
+	}
+	if p.handle != nil {
+		t.Fatalf("Expected Validate to not create a native engine.")
+	}
+}
+
+func TestMultipleLibraries(t *testing.T) {
+	requireNativeLibrary(t)
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/porcupine.wav")
+	pcm := readTestAudio(t, test_file)
+
+	libData, err := ioutil.ReadFile(defaultLibraryFile())
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	var instances []*Porcupine
+	for i := 0; i < 2; i++ {
+		libPath := filepath.Join(t.TempDir(), filepath.Base(defaultLibraryFile()))
+		if err := ioutil.WriteFile(libPath, libData, 0777); err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		p := &Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}, LibraryPath: libPath}
+		if err := p.Init(); err != nil {
+			t.Fatalf("%v", err)
+		}
+		defer p.Delete()
+		instances = append(instances, p)
+	}
+
+	if instances[0].native == instances[1].native || instances[0].native == nativePorcupine {
+		t.Fatalf("Expected each library file to be loaded separately.")
+	}
+
+	// interleave processing to make sure the instances don't share state
+	results := make([][]int, len(instances))
+	for i := 0; i < len(pcm)/FrameLength(); i++ {
+		for j, p := range instances {
+			result, err := p.Process(pcm[i*FrameLength() : (i+1)*FrameLength()])
+			if err != nil {
+				t.Fatalf("Failed to process frame: %v", err)
+			}
+			if result >= 0 {
+				results[j] = append(results[j], result)
+			}
+		}
+	}
+
+	for j := range instances {
+		if len(results[j]) != 1 || results[j][0] != 0 {
+			t.Fatalf("Failed to find keyword '%s' with library %d.", PORCUPINE, j)
+		}
+	}
+}
+
+func TestExtractionProgress(t *testing.T) {
+	requireNativeLibrary(t)
+
+	// files that are already extracted aren't written or reported again, so extract to a new directory
+	previousDir := getExtractionDir()
+	t.Cleanup(func() { SetExtractionDir(previousDir) })
+	dir := t.TempDir()
+	if err := SetExtractionDir(dir); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if _, err := getDefaultLibrary(); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	var events []ExtractionEvent
+	SetExtractionProgress(func(event ExtractionEvent) {
+		events = append(events, event)
+	})
+	defer SetExtractionProgress(nil)
+
+	foundLib := false
+	for _, event := range events {
+		// earlier tests may have extracted to directories that were removed since
+		if !strings.HasPrefix(event.File, dir) {
+			continue
+		}
+		info, err := os.Stat(event.File)
+		if err != nil {
+			t.Fatalf("Reported file '%s' does not exist: %v", event.File, err)
+		}
+		if info.Size() != event.Size {
+			t.Fatalf("Expected size %d for '%s', but got %d", info.Size(), event.File, event.Size)
+		}
+		if event.File == libName {
+			foundLib = true
+		}
+	}
+	if libName != "" && !foundLib {
+		t.Fatalf("Extraction of library '%s' was not reported", libName)
+	}
+}
+
+func TestExtractionEventsBounded(t *testing.T) {
+	extractionProgressMutex.Lock()
+	previousEvents := extractionEvents
+	extractionEvents = nil
+	extractionProgressMutex.Unlock()
+	defer func() {
+		extractionProgressMutex.Lock()
+		extractionEvents = previousEvents
+		extractionProgressMutex.Unlock()
+	}()
+
+	for i := 0; i < 3; i++ {
+		reportExtraction(ExtractionEvent{File: "/extracted/lib.so", Size: int64(i)})
+	}
+	for i := 0; i < 2*maxExtractionEvents; i++ {
+		reportExtraction(ExtractionEvent{File: fmt.Sprintf("/extracted/%d/model.pv", i)})
+	}
+
+	var events []ExtractionEvent
+	SetExtractionProgress(func(event ExtractionEvent) {
+		events = append(events, event)
+	})
+	defer SetExtractionProgress(nil)
+
+	if len(events) != maxExtractionEvents {
+		t.Fatalf("Expected the last %d events to be kept, but got %d", maxExtractionEvents, len(events))
+	}
+	expected := fmt.Sprintf("/extracted/%d/model.pv", 2*maxExtractionEvents-1)
+	if last := events[len(events)-1].File; last != expected {
+		t.Fatalf("Expected the most recent event for '%s', but got '%s'", expected, last)
+	}
+
+	extractionProgressMutex.Lock()
+	extractionEvents = nil
+	extractionProgressMutex.Unlock()
+	for i := 0; i < 3; i++ {
+		reportExtraction(ExtractionEvent{File: "/extracted/lib.so", Size: int64(i)})
+	}
+	events = nil
+	SetExtractionProgress(func(event ExtractionEvent) {
+		events = append(events, event)
+	})
+	if len(events) != 1 || events[0].Size != 2 {
+		t.Fatalf("Expected a single event for a file extracted repeatedly, but got %+v", events)
+	}
+}
+
+func TestProcessBytes(t *testing.T) {
+	requireNativeLibrary(t)
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/porcupine.wav")
+	f, err := os.Open(test_file)
+	if err != nil {
+		t.Fatalf("Could not read test file: %v", err)
+	}
+	defer f.Close()
+	data, err := readWAVHeader(f)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	pcmBytes, err := ioutil.ReadAll(data)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	err = p.Init()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	if _, err := p.ProcessBytes(pcmBytes[:FrameLength()*2-1]); err == nil {
+		t.Fatalf("Expected an error for a byte frame of the wrong size.")
+	}
+
+	// the raw bytes of the data chunk are processed as read, without converting them to samples first
+	frameBytes := FrameLength() * 2
+	detections := 0
+	for start := 0; start+frameBytes <= len(pcmBytes); start += frameBytes {
+		keywordIndex, err := p.ProcessBytes(pcmBytes[start : start+frameBytes])
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if keywordIndex == 0 {
+			detections++
+		}
+	}
+	if detections != 1 {
+		t.Fatalf("Expected 1 detection, but got %d", detections)
+	}
+}
+
+func TestProcessBytesByteOrder(t *testing.T) {
+	requireNativeLibrary(t)
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/porcupine.wav")
+	pcm := readTestAudio(t, test_file)
+
+	// the same audio encoded in both byte orders must give the same detections
+	orders := map[string]func(*Porcupine, []byte) (int, error){
+		"LE": (*Porcupine).ProcessBytesLE,
+		"BE": (*Porcupine).ProcessBytesBE,
+	}
+	byteOrders := map[string]binary.ByteOrder{"LE": binary.LittleEndian, "BE": binary.BigEndian}
+	for name, process := range orders {
+		p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+		err := p.Init()
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		frame := make([]byte, FrameLength()*2)
+		detections := 0
+		for i := 0; i < len(pcm)/FrameLength(); i++ {
+			for j, sample := range pcm[i*FrameLength() : (i+1)*FrameLength()] {
+				byteOrders[name].PutUint16(frame[j*2:], uint16(sample))
+			}
+			keywordIndex, err := process(&p, frame)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			if keywordIndex == 0 {
+				detections++
+			}
+		}
+		p.Delete()
+
+		if detections != 1 {
+			t.Fatalf("Expected 1 detection from %s bytes, but got %d", name, detections)
+		}
+	}
+}
+
+func TestCleanCache(t *testing.T) {
+	keywordPath := filepath.Join(t.TempDir(), "porcupine.ppn")
+	keywordBytes, _ := ioutil.ReadFile(testKeywordFile(t, PORCUPINE))
+	if err := ioutil.WriteFile(keywordPath, keywordBytes, 0644); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	p := Porcupine{KeywordPaths: []string{keywordPath}}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	os.Remove(keywordPath)
+	if err := p.Validate(); err != nil {
+		t.Fatalf("Expected the cached check to pass, but got: %v", err)
+	}
+
+	CleanCache()
+	if err := p.Validate(); err == nil {
+		t.Fatalf("Expected removed keyword file to be reported after CleanCache.")
+	}
+}
+
+func TestExtractFileSkipsMatchingFile(t *testing.T) {
+	dir := t.TempDir()
+	const srcFile = "embedded/lib/common/porcupine_params.pv"
+	extractedPath, err := extractFile(srcFile, dir)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(extractedPath, past, past); err != nil {
+		t.Fatalf("%v", err)
+	}
+	secondPath, err := extractFile(srcFile, dir)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if secondPath != extractedPath {
+		t.Fatalf("Expected '%s', but got '%s'", extractedPath, secondPath)
+	}
+	info, _ := os.Stat(extractedPath)
+	if !info.ModTime().Equal(past) {
+		t.Fatalf("Expected matching file not to be rewritten, but its modtime changed to %v", info.ModTime())
+	}
+
+	if err := ioutil.WriteFile(extractedPath, []byte("corrupt"), 0777); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if _, err := extractFile(srcFile, dir); err != nil {
+		t.Fatalf("%v", err)
+	}
+	extracted, _ := ioutil.ReadFile(extractedPath)
+	embedded, _ := embeddedFS.ReadFile(srcFile)
+	if !bytes.Equal(extracted, embedded) {
+		t.Fatalf("Expected a file that differs from the embedded one to be rewritten.")
+	}
+}
+
+func BenchmarkInit(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		p := Porcupine{BuiltInKeywords: []BuiltInKeyword{ALEXA, PORCUPINE, TERMINATOR}}
+		if err := p.Init(); err != nil {
+			b.Fatalf("%v", err)
+		}
+		p.Delete()
+	}
+}
+
+func TestDeleteAfterFailedInit(t *testing.T) {
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}, Sensitivities: []float32{2}}
+	if err := p.Init(); err == nil {
+		t.Fatalf("Expected Init to fail with an invalid sensitivity.")
+	}
+	if err := p.Delete(); err != nil {
+		t.Fatalf("Expected Delete after a failed Init to succeed, but got: %v", err)
+	}
+
+	var uninitialized Porcupine
+	if err := uninitialized.Delete(); err != nil {
+		t.Fatalf("Expected Delete without Init to succeed, but got: %v", err)
+	}
+}
+
+func TestSelfTest(t *testing.T) {
+	requireNativeLibrary(t)
+
+	if err := SelfTest(); err != nil {
+		t.Fatalf("%v", err)
+	}
+}
+
+func TestProcessEmptyFrame(t *testing.T) {
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	for _, pcm := range [][]int16{nil, {}} {
+		keywordIndex, err := p.Process(pcm)
+		var porcupineErr *PorcupineError
+		if !errors.As(err, &porcupineErr) || porcupineErr.StatusCode != INVALID_ARGUMENT {
+			t.Fatalf("Expected an INVALID_ARGUMENT error for an empty frame, but got: %v", err)
+		}
+		if keywordIndex != -1 {
+			t.Fatalf("Expected -1 for an empty frame, but got %d", keywordIndex)
+		}
+	}
+}
+
+func TestKeywords(t *testing.T) {
+	var uninitialized Porcupine
+	if uninitialized.Keywords() != nil || uninitialized.NumKeywords() != 0 {
+		t.Fatalf("Expected no keywords before Init, but got %v", uninitialized.Keywords())
+	}
+
+	keywordPath := testKeywordFile(t, BUMBLEBEE)
+	p := Porcupine{
+		BuiltInKeywords: []BuiltInKeyword{PORCUPINE, ALEXA},
+		KeywordPaths:    []string{keywordPath}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	expected := []string{keywordLabelFromPath(keywordPath), string(PORCUPINE), string(ALEXA)}
+	if !reflect.DeepEqual(p.Keywords(), expected) || p.NumKeywords() != len(expected) {
+		t.Fatalf("Expected keywords %v, but got %v", expected, p.Keywords())
+	}
+
+	p.Keywords()[0] = "modified"
+	if p.Keywords()[0] != expected[0] {
+		t.Fatalf("Expected Keywords to return a copy.")
+	}
+}
+
+func TestProcessMultiple(t *testing.T) {
+	requireNativeLibrary(t)
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/multiple_keywords.wav")
+
+	alexa := Porcupine{BuiltInKeywords: []BuiltInKeyword{ALEXA}}
+	porcupine := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	for _, p := range []*Porcupine{&alexa, &porcupine} {
+		if err := p.Init(); err != nil {
+			t.Fatalf("%v", err)
+		}
+		defer p.Delete()
+	}
+
+	pcm := readTestAudio(t, test_file)
+	counts := make([]int, 2)
+	for i := 0; i < len(pcm)/FrameLength(); i++ {
+		results, err := ProcessMultiple(pcm[i*FrameLength():(i+1)*FrameLength()], &alexa, &porcupine)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		for j, result := range results {
+			if result >= 0 {
+				counts[j]++
+			}
+		}
+	}
+	if counts[0] != 1 || counts[1] != 2 {
+		t.Fatalf("Expected 1 detection of '%s' and 2 of '%s', but got %v", ALEXA, PORCUPINE, counts)
+	}
+
+	var uninitialized Porcupine
+	results, err := ProcessMultiple(make([]int16, FrameLength()), &alexa, &uninitialized)
+	var porcupineErr *PorcupineError
+	if !errors.As(err, &porcupineErr) || porcupineErr.StatusCode != INVALID_STATE {
+		t.Fatalf("Expected an INVALID_STATE error for the uninitialized instance, but got: %v", err)
+	}
+	if !strings.HasPrefix(err.Error(), "instance 1:") {
+		t.Fatalf("Expected error to name the failed instance, but got: %v", err)
+	}
+	if results[1] != -1 {
+		t.Fatalf("Expected -1 for the failed instance, but got %d", results[1])
+	}
+}
+
+func TestSetSensitivities(t *testing.T) {
+	requireNativeLibrary(t)
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/porcupine.wav")
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}, Sensitivities: []float32{0.5}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	for i := 0; i < 10; i++ {
+		if _, err := p.Process(make([]int16, FrameLength())); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+
+	if err := p.SetSensitivities([]float32{0.5, 0.5}); err == nil {
+		t.Fatalf("Expected an error for a sensitivity count mismatch.")
+	}
+	if err := p.SetSensitivities([]float32{1.5}); err == nil {
+		t.Fatalf("Expected an error for an out-of-range sensitivity.")
+	}
+	if err := p.SetSensitivities([]float32{0.7}); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if p.Sensitivities[0] != 0.7 {
+		t.Fatalf("Expected Sensitivities to be updated, but got %v", p.Sensitivities)
+	}
+	if p.ProcessedDuration() == 0 {
+		t.Fatalf("Expected the frame counter to be kept.")
+	}
+
+	detections, err := p.ProcessFile(context.Background(), test_file)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(detections) != 1 || detections[0].Index != 0 {
+		t.Fatalf("Expected a single detection of '%s' after changing sensitivity, but got %v", PORCUPINE, detections)
+	}
+}
+
+func TestSetSensitivitiesKeepsConfiguration(t *testing.T) {
+	native := &testNative{version: "1.9.0"}
+	libPath := registerTestNative(t, native)
+
+	set := NewKeywordSet().AddBuiltIn(ALEXA, 0.5).AddBuiltIn(PORCUPINE, 0.5)
+	for _, p := range []*Porcupine{
+		{LibraryPath: libPath, KeywordSet: set},
+		{LibraryPath: libPath, BuiltInKeywords: []BuiltInKeyword{ALEXA, PORCUPINE},
+			BuiltInSensitivities: map[BuiltInKeyword]float32{ALEXA: 0.5}},
+	} {
+		if err := p.Init(); err != nil {
+			t.Fatalf("%v", err)
+		}
+		if err := p.SetSensitivities([]float32{0.3, 0.8}); err != nil {
+			t.Fatalf("%v", err)
+		}
+		if err := p.Validate(); err != nil {
+			t.Fatalf("Expected the configuration to stay valid, but got %v", err)
+		}
+
+		var buf bytes.Buffer
+		if err := p.SaveState(&buf); err != nil {
+			t.Fatalf("%v", err)
+		}
+		restored, err := LoadState(&buf)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if sensitivities := restored.Config().Sensitivities; !reflect.DeepEqual(sensitivities, []float32{0.3, 0.8}) {
+			t.Fatalf("Expected restored sensitivities [0.3 0.8], but got %v", sensitivities)
+		}
+		restored.Delete()
+
+		p.Delete()
+		if err := p.Init(); err != nil {
+			t.Fatalf("Expected Init to succeed again, but got %v", err)
+		}
+		if sensitivities := p.Config().Sensitivities; !reflect.DeepEqual(sensitivities, []float32{0.3, 0.8}) {
+			t.Fatalf("Expected sensitivities [0.3 0.8] after Init, but got %v", sensitivities)
+		}
+		p.Delete()
+	}
+
+	for _, entry := range set.entries {
+		if entry.sensitivity != 0.5 {
+			t.Fatalf("Expected the caller's KeywordSet to be left unchanged, but got %+v", set.entries)
+		}
+	}
+}
+
+func TestKeywordData(t *testing.T) {
+	requireNativeLibrary(t)
+
+	keywordData, err := ioutil.ReadFile(testKeywordFile(t, PORCUPINE))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	modelData, err := ioutil.ReadFile(testModelFile(t))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	invalid := Porcupine{ModelPath: testModelFile(t), ModelData: modelData, KeywordData: [][]byte{keywordData}}
+	if err := invalid.Validate(); err == nil {
+		t.Fatalf("Expected an error when both ModelPath and ModelData are set.")
+	}
+
+	p := Porcupine{ModelData: modelData, KeywordData: [][]byte{keywordData}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/porcupine.wav")
+	pcm := readTestAudio(t, test_file)
+	var labels []string
+	for i := 0; i < len(pcm)/FrameLength(); i++ {
+		label, err := p.ProcessLabel(pcm[i*FrameLength() : (i+1)*FrameLength()])
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if label != "" {
+			labels = append(labels, label)
+		}
+	}
+
+	if len(labels) != 1 || labels[0] != "keyword_data_0" {
+		t.Fatalf("Expected a single detection of 'keyword_data_0', but got %v", labels)
+	}
+}
+
+func TestSetModelFS(t *testing.T) {
+	requireNativeLibrary(t)
+
+	keywordData, err := ioutil.ReadFile(testKeywordFile(t, PORCUPINE))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	modelData, err := ioutil.ReadFile(testModelFile(t))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	fsys := fstest.MapFS{
+		"models/model.pv":      &fstest.MapFile{Data: modelData},
+		"keywords/my_word.ppn": &fstest.MapFile{Data: keywordData},
+	}
+
+	missing := Porcupine{KeywordPaths: []string{"keywords/my_word.ppn"}}
+	missing.SetModelFS(fsys, "models/missing.pv")
+	if err := missing.Init(); err == nil {
+		missing.Delete()
+		t.Fatalf("Expected an error for a model missing from the file system.")
+	}
+
+	p := Porcupine{KeywordPaths: []string{"keywords/my_word.ppn"}}
+	p.SetModelFS(fsys, "models/model.pv")
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/porcupine.wav")
+	pcm := readTestAudio(t, test_file)
+	var labels []string
+	for i := 0; i < len(pcm)/FrameLength(); i++ {
+		label, err := p.ProcessLabel(pcm[i*FrameLength() : (i+1)*FrameLength()])
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if label != "" {
+			labels = append(labels, label)
+		}
+	}
+
+	if len(labels) != 1 || labels[0] != "my_word" {
+		t.Fatalf("Expected a single detection of 'my_word', but got %v", labels)
+	}
+}
+
+func TestProcessedDuration(t *testing.T) {
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	if p.ProcessedDuration() != 0 {
+		t.Fatalf("Expected no processed audio after Init, but got %v", p.ProcessedDuration())
+	}
+
+	frames := SampleRate() / FrameLength() * 2
+	for i := 0; i < frames; i++ {
+		if _, err := p.Process(make([]int16, FrameLength())); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+	expected := time.Duration(frames*FrameLength()) * time.Second / time.Duration(SampleRate())
+	if p.ProcessedDuration() != expected {
+		t.Fatalf("Expected %v of processed audio, but got %v", expected, p.ProcessedDuration())
+	}
+
+	if err := p.Reset(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if p.ProcessedDuration() != 0 {
+		t.Fatalf("Expected Reset to clear the processed duration, but got %v", p.ProcessedDuration())
+	}
+}
+
+func TestProcessedDurationLongStream(t *testing.T) {
+	native := &testNative{version: "1.9.0", processResults: []int{0}}
+	p := Porcupine{LibraryPath: registerTestNative(t, native), BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	// a week of frames, past the point where frames * frameLength * time.Second overflows
+	week := 7 * 24 * time.Hour
+	p.frameCount = int64(week/time.Second) * int64(SampleRate()) / int64(FrameLength())
+	if p.ProcessedDuration() != week {
+		t.Fatalf("Expected %v of processed audio, but got %v", week, p.ProcessedDuration())
+	}
+
+	detection, err := p.ProcessDetailed(make([]int16, FrameLength()))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if detection == nil || detection.Timestamp <= week {
+		t.Fatalf("Expected a detection after %v, but got %+v", week, detection)
+	}
+}
+
+func TestInvalidSensitivity(t *testing.T) {
+	for _, sensitivity := range []float32{1.5, -0.1, float32(math.NaN())} {
+		p := Porcupine{
+			BuiltInKeywords: []BuiltInKeyword{PORCUPINE, ALEXA},
+			Sensitivities:   []float32{0.5, sensitivity}}
+		err := p.Init()
+		if err == nil {
+			p.Delete()
+			t.Fatalf("Expected Init to fail with sensitivity %v", sensitivity)
+		}
+		var porcupineErr *PorcupineError
+		if !errors.As(err, &porcupineErr) || porcupineErr.StatusCode != INVALID_ARGUMENT {
+			t.Fatalf("Expected an INVALID_ARGUMENT error, but got: %v", err)
+		}
+		if !strings.Contains(err.Error(), "index 1") {
+			t.Fatalf("Expected error to name the index of the invalid sensitivity, but got: %v", err)
+		}
+		if p.handle != nil {
+			t.Fatalf("Expected Init to not create a native engine.")
+		}
+	}
+}
+
+func TestDeleteTwice(t *testing.T) {
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err := p.Delete(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err := p.Delete(); err != nil {
+		t.Fatalf("Expected second Delete to succeed, but got: %v", err)
+	}
+
+	var closer io.Closer = &p
+	if err := closer.Close(); err != nil {
+		t.Fatalf("Expected Close after Delete to succeed, but got: %v", err)
+	}
+	if _, err := p.Process(make([]int16, FrameLength())); err == nil {
+		t.Fatalf("Expected Process after Delete to fail.")
+	}
+}
+
+func TestArm(t *testing.T) {
+	requireNativeLibrary(t)
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/porcupine.wav")
+	pcm := readTestAudio(t, test_file)
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}, MinDetectionGap: time.Hour}
+	err := p.Init()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	countDetections := func() int {
+		detections := 0
+		for i := 0; i < len(pcm)/FrameLength(); i++ {
+			keywordIndex, err := p.Process(pcm[i*FrameLength() : (i+1)*FrameLength()])
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			if keywordIndex == 0 {
+				detections++
+			}
+		}
+		return detections
+	}
+
+	if detections := countDetections(); detections != 1 {
+		t.Fatalf("Expected 1 detection, but got %d", detections)
+	}
+	if detections := countDetections(); detections != 0 {
+		t.Fatalf("Expected repeat within MinDetectionGap to be suppressed, but got %d detections", detections)
+	}
+
+	p.ArmAll()
+	if detections := countDetections(); detections != 1 {
+		t.Fatalf("Expected 1 detection after ArmAll, but got %d", detections)
+	}
+
+	p.Arm(0)
+	if detections := countDetections(); detections != 1 {
+		t.Fatalf("Expected 1 detection after Arm, but got %d", detections)
+	}
+}
+
+func TestArmConcurrentWithProcess(t *testing.T) {
+	native := &testNative{version: "1.9.0", processResults: []int{0, 0, 0, 0, 0, 0, 0, 0}}
+	p := Porcupine{LibraryPath: registerTestNative(t, native), BuiltInKeywords: []BuiltInKeyword{PORCUPINE},
+		MinDetectionGap: time.Hour}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	// run with -race to check that re-arming doesn't race with the detection state updated by Process
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			p.Arm(0)
+			p.ArmAll()
+			p.RecentDetections()
+		}
+	}()
+	frame := make([]int16, FrameLength())
+	for i := 0; i < 100; i++ {
+		if _, err := p.Process(frame); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+	<-done
+
+	if detections := p.RecentDetections(); len(detections) == 0 {
+		t.Fatalf("Expected at least one detection, but got none")
+	}
+}
+
+func TestTooManyKeywords(t *testing.T) {
+	keywords := make([]BuiltInKeyword, MaxKeywords()+1)
+	for i := range keywords {
+		keywords[i] = PORCUPINE
+	}
+
+	p := Porcupine{BuiltInKeywords: keywords}
+	err := p.Init()
+	if err == nil {
+		p.Delete()
+		t.Fatalf("Expected Init to fail with %d keywords.", len(keywords))
+	}
+	expected := fmt.Sprintf("%d keywords requested but engine supports at most %d", len(keywords), MaxKeywords())
+	if !strings.Contains(err.Error(), expected) {
+		t.Fatalf("Expected error containing '%s', but got: %v", expected, err)
+	}
+
+	p.BuiltInKeywords = keywords[:MaxKeywords()]
+	if err := p.Validate(); err != nil {
+		t.Fatalf("Expected %d keywords to be valid, but got: %v", MaxKeywords(), err)
+	}
+}
+
+// native library implemented in Go, for testing behaviour the bundled library can't produce
+type testNative struct {
+	version    string
+	initStatus PvStatus
+
+	// statuses returned by successive init calls before falling back to initStatus
+	initStatuses []PvStatus
+
+	// AccessKey passed to the last init
+	accessKey string
+
+	errorStack []string
+
+	// if set, init blocks until it is closed
+	initGate chan struct{}
+
+	// if set, receives a value for every delete
+	deleted chan struct{}
+
+	// keyword indices returned by successive process calls, -1 once exhausted
+	processResults []int
+
+	// if set, process blocks until it is closed
+	processGate chan struct{}
+}
+
+func (np *testNative) nativeInit(porcupine *Porcupine) PvStatus {
+	if np.initGate != nil {
+		<-np.initGate
+	}
+	np.accessKey = porcupine.AccessKey
+	status := np.initStatus
+	if len(np.initStatuses) > 0 {
+		status, np.initStatuses = np.initStatuses[0], np.initStatuses[1:]
+	}
+	if status == SUCCESS {
+		porcupine.handle = unsafe.Pointer(np)
+	}
+	return status
+}
+
+func (np *testNative) nativeProcess(porcupine *Porcupine, pcm []int16) (PvStatus, int) {
+	if np.processGate != nil {
+		<-np.processGate
+	}
+	if len(np.processResults) == 0 {
+		return SUCCESS, -1
+	}
+	keywordIndex := np.processResults[0]
+	np.processResults = np.processResults[1:]
+	return SUCCESS, keywordIndex
+}
+
+func (np *testNative) nativeDelete(porcupine *Porcupine) {
+	if np.deleted != nil {
+		np.deleted <- struct{}{}
+	}
+}
+
+func (np *testNative) nativeSampleRate() int { return 16000 }
+
+func (np *testNative) nativeFrameLength() int { return 512 }
+
+func (np *testNative) nativeVersion() string { return np.version }
+
+func (np *testNative) nativeErrorStack() []string { return np.errorStack }
+
+// Registers `native` as the library loaded from the returned path, for use as `LibraryPath`.
+func registerTestNative(t *testing.T, native nativePorcupineInterface) string {
+	libPath := filepath.Join(t.TempDir(), "libpv_porcupine_test.so")
+
+	nativeLibrariesMutex.Lock()
+	nativeLibraries[libPath] = native
+	nativeLibrariesMutex.Unlock()
+
+	t.Cleanup(func() {
+		nativeLibrariesMutex.Lock()
+		delete(nativeLibraries, libPath)
+		nativeLibrariesMutex.Unlock()
+	})
+	return libPath
+}
+
+// Copies a file to a temporary directory, e.g. to stand in for a model or keyword file that isn't embedded.
+func copyTestFile(t *testing.T, path string) string {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	copyPath := filepath.Join(t.TempDir(), filepath.Base(path))
+	if err := ioutil.WriteFile(copyPath, data, 0644); err != nil {
+		t.Fatalf("%v", err)
+	}
+	return copyPath
+}
+
+func TestAccessKey(t *testing.T) {
+	native := &testNative{version: "2.0.0"}
+	libPath := registerTestNative(t, native)
+
+	// the embedded files are for 1.9, so use files that stand in for ones built for 2.0
+	p := Porcupine{
+		ModelPath:    copyTestFile(t, testModelFile(t)),
+		KeywordPaths: []string{copyTestFile(t, testKeywordFile(t, PORCUPINE))},
+		LibraryPath:  libPath}
+	err := p.Init()
+	if err == nil || !strings.Contains(err.Error(), "AccessKey is required") {
+		t.Fatalf("Expected an error for a missing AccessKey, but got: %v", err)
+	}
+
+	p.AccessKey = "test-access-key"
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if native.accessKey != p.AccessKey {
+		t.Fatalf("Expected AccessKey '%s' to be passed to init, but got '%s'", p.AccessKey, native.accessKey)
+	}
+	p.Delete()
+
+	native.initStatus = ACTIVATION_ERROR
+	err = p.Init()
+	if err == nil {
+		t.Fatalf("Expected Init to fail with an activation error.")
+	}
+	if !strings.HasPrefix(err.Error(), pvStatusToString(ACTIVATION_ERROR)) ||
+		strings.Contains(err.Error(), pvStatusToString(INVALID_ARGUMENT)) {
+		t.Fatalf("Expected an activation error distinct from INVALID_ARGUMENT, but got: %v", err)
+	}
+
+	// libraries before 2.0 don't take an AccessKey
+	if requiresAccessKey("1.9.0") || !requiresAccessKey("2.1.0") {
+		t.Fatalf("Expected only versions 2.0 and later to require an AccessKey.")
+	}
+}
+
+func TestVersionMismatch(t *testing.T) {
+	native := &testNative{version: "2.0.0"}
+	libPath := registerTestNative(t, native)
+
+	for _, p := range []*Porcupine{
+		{AccessKey: "test-access-key", BuiltInKeywords: []BuiltInKeyword{PORCUPINE}, LibraryPath: libPath},
+		{AccessKey: "test-access-key", KeywordPaths: []string{copyTestFile(t, testKeywordFile(t, PORCUPINE))},
+			LibraryPath: libPath},
+	} {
+		err := p.Init()
+		if err == nil || !strings.Contains(err.Error(), "Library version 2.0.0 does not match expected version "+
+			embeddedAssetsVersion) {
+			t.Fatalf("Expected a version mismatch error, but got: %v", err)
+		}
+	}
+
+	if !versionsCompatible("1.9.2", "1.9.0") || versionsCompatible("1.8.0", "1.9.0") ||
+		versionsCompatible("2.0.0", "1.9.0") {
+		t.Fatalf("Expected only libraries with the same major and minor version to be compatible.")
+	}
+}
+
+func TestProcessLabel(t *testing.T) {
+	requireNativeLibrary(t)
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/multiple_keywords.wav")
+	pcm := readTestAudio(t, test_file)
+
+	p := Porcupine{
+		KeywordPaths:    []string{testKeywordFile(t, BUMBLEBEE)},
+		BuiltInKeywords: []BuiltInKeyword{ALEXA, PORCUPINE}}
+	err := p.Init()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	var labels []string
+	for i := 0; i < len(pcm)/FrameLength(); i++ {
+		label, err := p.ProcessLabel(pcm[i*FrameLength() : (i+1)*FrameLength()])
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if label != "" {
+			labels = append(labels, label)
+		}
+	}
+
+	bumblebeeLabel := keywordLabelFromPath(testKeywordFile(t, BUMBLEBEE))
+	expected := []string{string(PORCUPINE), string(ALEXA), bumblebeeLabel, string(PORCUPINE)}
+	if !reflect.DeepEqual(labels, expected) {
+		t.Fatalf("Expected labels %v, but got %v", expected, labels)
+	}
+}
+
+func TestPorcupineError(t *testing.T) {
+	p := Porcupine{ModelPath: "/does/not/exist.pv", BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	err := p.Init()
+
+	var porcupineErr *PorcupineError
+	if !errors.As(err, &porcupineErr) {
+		t.Fatalf("Expected a PorcupineError, but got: %v", err)
+	}
+	if porcupineErr.StatusCode != INVALID_ARGUMENT {
+		t.Fatalf("Expected status %s for a missing model, but got %s",
+			pvStatusToString(INVALID_ARGUMENT), pvStatusToString(porcupineErr.StatusCode))
+	}
+
+	_, err = p.Process(make([]int16, FrameLength()))
+	if !errors.As(err, &porcupineErr) || porcupineErr.StatusCode != INVALID_STATE {
+		t.Fatalf("Expected status %s for Process before Init, but got: %v", pvStatusToString(INVALID_STATE), err)
+	}
+}
+
+func TestErrorStack(t *testing.T) {
+	native := &testNative{
+		version:    "2.0.0",
+		initStatus: ACTIVATION_REFUSED,
+		errorStack: []string{"AccessKey is invalid", "activation failed"}}
+	libPath := registerTestNative(t, native)
+
+	p := Porcupine{
+		AccessKey:    "test-access-key",
+		ModelPath:    copyTestFile(t, testModelFile(t)),
+		KeywordPaths: []string{copyTestFile(t, testKeywordFile(t, PORCUPINE))},
+		LibraryPath:  libPath}
+	err := p.Init()
+
+	var porcupineErr *PorcupineError
+	if !errors.As(err, &porcupineErr) {
+		t.Fatalf("Expected a PorcupineError, but got: %v", err)
+	}
+	if !reflect.DeepEqual(porcupineErr.InnerMessage, native.errorStack) {
+		t.Fatalf("Expected error stack %v, but got %v", native.errorStack, porcupineErr.InnerMessage)
+	}
+	for _, message := range native.errorStack {
+		if !strings.Contains(err.Error(), message) {
+			t.Fatalf("Expected error to include '%s', but got: %v", message, err)
+		}
+	}
+}
+
+func TestProcessBuffer(t *testing.T) {
+	requireNativeLibrary(t)
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/multiple_keywords.wav")
+	pcm := readTestAudio(t, test_file)
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{ALEXA, PORCUPINE}}
+	err := p.Init()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	// chunks that don't line up with frames
+	var results []int
+	for start := 0; start < len(pcm); start += 1000 {
+		end := start + 1000
+		if end > len(pcm) {
+			end = len(pcm)
+		}
+		keywordIndices, err := p.ProcessBuffer(pcm[start:end])
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		results = append(results, keywordIndices...)
+	}
+
+	if !reflect.DeepEqual(results, []int{1, 0, 1}) {
+		t.Fatalf("Expected keyword indices [1 0 1], but got %v", results)
+	}
+	if p.frameCount != int64(len(pcm)/FrameLength()) {
+		t.Fatalf("Expected %d frames to be processed, but got %d", len(pcm)/FrameLength(), p.frameCount)
+	}
+	if len(p.pendingPCM) != len(pcm)%FrameLength() {
+		t.Fatalf("Expected %d samples to be retained, but got %d", len(pcm)%FrameLength(), len(p.pendingPCM))
+	}
+}
+
+func TestProcessChannel(t *testing.T) {
+	requireNativeLibrary(t)
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/multiple_keywords.wav")
+	pcm := readTestAudio(t, test_file)
+
+	// keywords on the right channel, silence on the left
+	stereo := make([]int16, 2*len(pcm))
+	for i, sample := range pcm {
+		stereo[2*i+1] = sample
+	}
+
+	for _, tc := range []struct {
+		channelIndex int
+		expected     []int
+	}{
+		{0, nil},
+		{1, []int{1, 0, 1}},
+	} {
+		p := Porcupine{BuiltInKeywords: []BuiltInKeyword{ALEXA, PORCUPINE}}
+		if err := p.Init(); err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		// chunks of whole sample pairs that don't line up with frames
+		var results []int
+		for start := 0; start < len(stereo); start += 1002 {
+			end := start + 1002
+			if end > len(stereo) {
+				end = len(stereo)
+			}
+			keywordIndices, err := p.ProcessChannel(stereo[start:end], 2, tc.channelIndex)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			results = append(results, keywordIndices...)
+		}
+		if len(p.pendingPCM) != len(pcm)%FrameLength() {
+			t.Fatalf("Expected %d samples to be retained, but got %d", len(pcm)%FrameLength(), len(p.pendingPCM))
+		}
+		p.Delete()
+
+		if !reflect.DeepEqual(results, tc.expected) {
+			t.Fatalf("Expected keyword indices %v on channel %d, but got %v", tc.expected, tc.channelIndex, results)
+		}
+	}
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+	for _, tc := range []struct {
+		length, channels, channelIndex int
+	}{
+		{1024, 2, 2},
+		{1024, 2, -1},
+		{1024, 0, 0},
+		{1023, 2, 0},
+	} {
+		if _, err := p.ProcessChannel(make([]int16, tc.length), tc.channels, tc.channelIndex); err == nil {
+			t.Fatalf("Expected an error for %d samples, %d channels and channel index %d",
+				tc.length, tc.channels, tc.channelIndex)
+		}
+	}
+}
+
+func TestUnavailableLibraryHelpers(t *testing.T) {
+	nativePorcupineMutex.Lock()
+	previousPath, previousLibrary := defaultLibraryPath, nativePorcupine
+	defaultLibraryPath, nativePorcupine, nativePorcupineErr = "/does/not/exist.so", nil, nil
+	nativePorcupineMutex.Unlock()
+	t.Cleanup(func() {
+		nativePorcupineMutex.Lock()
+		defaultLibraryPath, nativePorcupine, nativePorcupineErr = previousPath, previousLibrary, nil
+		nativePorcupineMutex.Unlock()
+	})
+
+	// the sample rate and frame length are 0 without a library, so nothing may divide by them
+	if _, err := NewConverter(48000, 2); err == nil {
+		t.Fatalf("Expected NewConverter to fail without a library.")
+	}
+	if _, err := NewResampler(48000); err == nil {
+		t.Fatalf("Expected NewResampler to fail without a library.")
+	}
+	if _, err := Resample(make([]int16, 480), 48000); err == nil {
+		t.Fatalf("Expected Resample to fail without a library.")
+	}
+	if start, end := PostRollRange(100, time.Second, 1000); start != end {
+		t.Fatalf("Expected an empty post-roll range without a library, but got [%d, %d)", start, end)
+	}
+	if start, end := FrameToSampleRange(3); start != 0 || end != 0 {
+		t.Fatalf("Expected an empty frame range without a library, but got [%d, %d)", start, end)
+	}
+	if SampleToTime(16000) != 0 {
+		t.Fatalf("Expected no time without a library, but got %v", SampleToTime(16000))
+	}
+
+	var p Porcupine
+	if err := p.Run(context.Background(), nil, nil); err == nil {
+		t.Fatalf("Expected Run to fail on an uninitialized instance.")
+	}
+}
+
+func TestLibraryError(t *testing.T) {
+	if err := LibraryError(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if FrameLength() <= 0 || SampleRate() <= 0 || Version() == "" {
+		t.Fatalf("Expected values from the loaded library, but got %d, %d, '%s'", FrameLength(), SampleRate(), Version())
+	}
+
+	nativePorcupineMutex.Lock()
+	previousPath, previousLibrary := defaultLibraryPath, nativePorcupine
+	defaultLibraryPath, nativePorcupine = "/does/not/exist.so", nil
+	nativePorcupineMutex.Unlock()
+	t.Cleanup(func() {
+		nativePorcupineMutex.Lock()
+		defaultLibraryPath, nativePorcupine, nativePorcupineErr = previousPath, previousLibrary, nil
+		nativePorcupineMutex.Unlock()
+	})
+
+	if LibraryError() == nil {
+		t.Fatalf("Expected an error for a missing library.")
+	}
+	if FrameLength() != 0 || SampleRate() != 0 || Version() != "" {
+		t.Fatalf("Expected zero values without a library, but got %d, %d, '%s'", FrameLength(), SampleRate(), Version())
+	}
+}
+
+func TestSetLibraryPath(t *testing.T) {
+	requireNativeLibrary(t)
+
+	nativePorcupineMutex.Lock()
+	previousPath, previousLibrary := defaultLibraryPath, nativePorcupine
+	nativePorcupineMutex.Unlock()
+	t.Cleanup(func() {
+		nativePorcupineMutex.Lock()
+		defaultLibraryPath, nativePorcupine = previousPath, previousLibrary
+		nativePorcupineMutex.Unlock()
+	})
+
+	if err := SetLibraryPath("/does/not/exist.so"); err == nil {
+		t.Fatalf("Expected an error for a missing library.")
+	}
+
+	libData, err := ioutil.ReadFile(defaultLibraryFile())
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	libPath := filepath.Join(t.TempDir(), filepath.Base(defaultLibraryFile()))
+	if err := ioutil.WriteFile(libPath, libData, 0777); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err := SetLibraryPath(libPath); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	library, _ := getNativeLibrary(libPath)
+	if p.native != library || p.native == previousLibrary {
+		t.Fatalf("Expected instance to use the library set with SetLibraryPath.")
+	}
+}
+
+func TestSetExtractionDir(t *testing.T) {
+	if err := SetExtractionDir(""); err == nil {
+		t.Fatalf("Expected an error for an empty directory.")
+	}
+
+	previousDir := getExtractionDir()
+	dir := t.TempDir()
+	t.Cleanup(func() {
+		if err := SetExtractionDir(previousDir); err != nil {
+			t.Fatalf("%v", err)
+		}
+	})
+	if err := SetExtractionDir(dir); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	if !strings.HasPrefix(p.modelPath, dir) {
+		t.Fatalf("Expected model to be extracted to '%s', but got '%s'", dir, p.modelPath)
+	}
+	if !strings.HasPrefix(p.keywordPaths[0], dir) {
+		t.Fatalf("Expected keyword file to be extracted to '%s', but got '%s'", dir, p.keywordPaths[0])
+	}
+}
+
+func TestCleanupExtractedFiles(t *testing.T) {
+	previousDir := getExtractionDir()
+	dir := filepath.Join(t.TempDir(), "porcupine")
+	t.Cleanup(func() {
+		if err := SetExtractionDir(previousDir); err != nil {
+			t.Fatalf("%v", err)
+		}
+	})
+	if err := SetExtractionDir(dir); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	p.Delete()
+
+	if err := CleanupExtractedFiles(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("Expected extraction directory to be removed, but got: %v", err)
+	}
+
+	p = Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("Expected files to be extracted again after cleanup, but got: %v", err)
+	}
+	p.Delete()
+}
+
+func TestRaspberryPiLibrary(t *testing.T) {
+
+	defaultPlatformDetector, defaultCPUInfoReader := platformDetector, cpuInfoReader
+	defer func() { platformDetector, cpuInfoReader = defaultPlatformDetector, defaultCPUInfoReader }()
+
+	tests := []struct {
+		arch     string
+		cpuPart  string
+		expected string
+	}{
+		{"arm", "0xb76", "arm11"},
+		{"arm", "0xc07", "cortex-a7"},
+		{"arm", "0xd03", "cortex-a53"},
+		{"arm", "0xd08", "cortex-a72"},
+		{"arm64", "0xd03", "cortex-a53-aarch64"},
+		{"arm64", "0xd08", "cortex-a72-aarch64"},
+		{"arm64", "0xc07", ""},
+		{"arm", "0xd05", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.arch+"/"+tt.cpuPart, func(t *testing.T) {
+			platformDetector = func() (string, string) { return "linux", tt.arch }
+			cpuInfoReader = func() ([]byte, error) {
+				return []byte("processor\t: 0\nCPU implementer\t: 0x41\nCPU part\t: " + tt.cpuPart + "\n"), nil
+			}
+
+			libPath, err := embeddedLibraryFile()
+			if tt.expected == "" {
+				var platformErr *UnsupportedPlatformError
+				if !errors.As(err, &platformErr) {
+					t.Fatalf("Expected UnsupportedPlatformError, but got %v", err)
+				}
+				t.Logf("%v", err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			expectedPath := "embedded/lib/raspberry-pi/" + tt.expected + "/libpv_porcupine.so"
+			if libPath != expectedPath {
+				t.Fatalf("Expected library %s, but got %s", expectedPath, libPath)
+			}
+			if platform, err := getOS(); err != nil || platform != "raspberry-pi" {
+				t.Fatalf("Expected keyword files for raspberry-pi, but got '%s' (%v)", platform, err)
+			}
+		})
+	}
+}
+
+func TestBadLibraryPath(t *testing.T) {
+	notALibrary := filepath.Join(t.TempDir(), "libpv_porcupine.so")
+	if err := ioutil.WriteFile(notALibrary, []byte("not a shared library"), 0777); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	for _, libPath := range []string{"/does/not/exist.so", notALibrary} {
+		p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}, LibraryPath: libPath}
+		err := p.Init()
+		if err == nil {
+			p.Delete()
+			t.Fatalf("Expected an error loading library from %s.", libPath)
+		}
+		t.Logf("%v", err)
+	}
+}
+
+func TestConcurrentProcess(t *testing.T) {
+	requireNativeLibrary(t)
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/porcupine.wav")
+	pcm := readTestAudio(t, test_file)
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	err := p.Init()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	const numGoroutines = 4
+	var wg sync.WaitGroup
+	errs := make(chan error, numGoroutines)
+	for g := 0; g < numGoroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < len(pcm)/FrameLength(); i++ {
+				if _, err := p.Process(pcm[i*FrameLength() : (i+1)*FrameLength()]); err != nil {
+					errs <- err
+					return
+				}
+			}
+			if _, err := p.ProcessBuffer(pcm[:FrameLength()+100]); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("%v", err)
+	}
+	expectedFrames := int64(numGoroutines * (len(pcm)/FrameLength() + 1))
+	if p.frameCount != expectedFrames {
+		t.Fatalf("Expected %d frames to be processed, but got %d", expectedFrames, p.frameCount)
+	}
+}
+
+func TestValidateKeywordFile(t *testing.T) {
+	modelPath := testModelFile(t)
+	keywordPath := testKeywordFile(t, PORCUPINE)
+	if err := ValidateKeywordFile(modelPath, keywordPath); err != nil {
+		t.Fatalf("Expected a valid keyword file, but got %v", err)
+	}
+	if err := ValidateKeywordFile("", keywordPath); err != nil {
+		t.Fatalf("Expected a valid keyword file with the default model, but got %v", err)
+	}
+
+	keywordData, err := ioutil.ReadFile(keywordPath)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	dir := t.TempDir()
+	otherPlatform := "wasm"
+	if platform, _ := getOS(); platform == otherPlatform {
+		otherPlatform = "ios"
+	}
+	wrongPlatformPath := filepath.Join(dir, "porcupine_"+otherPlatform+".ppn")
+	wrongExtensionPath := filepath.Join(dir, "porcupine.txt")
+	for _, path := range []string{wrongPlatformPath, wrongExtensionPath} {
+		if err := ioutil.WriteFile(path, keywordData, 0644); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		modelPath   string
+		keywordPath string
+		expected    string
+	}{
+		{"missing keyword file", modelPath, filepath.Join(dir, "missing.ppn"), "could not be found"},
+		{"directory", modelPath, dir, "not a regular file"},
+		{"wrong extension", modelPath, wrongExtensionPath, "not a .ppn file"},
+		{"wrong platform", modelPath, wrongPlatformPath, "built for " + otherPlatform},
+		{"missing model", filepath.Join(dir, "missing.pv"), keywordPath, "model file could not be found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateKeywordFile(tt.modelPath, tt.keywordPath)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Fatalf("Expected an error containing '%s', but got %v", tt.expected, err)
+			}
+		})
+	}
+
+	t.Run("garbage", func(t *testing.T) {
+		requireNativeLibrary(t)
+
+		garbagePath := filepath.Join(dir, "garbage.ppn")
+		if err := ioutil.WriteFile(garbagePath, bytes.Repeat([]byte("not a keyword "), 100), 0644); err != nil {
+			t.Fatalf("%v", err)
+		}
+		err := ValidateKeywordFile(modelPath, garbagePath)
+		if err == nil || !strings.Contains(err.Error(), "could not be loaded") {
+			t.Fatalf("Expected an error for a garbage keyword file, but got %v", err)
+		}
+		t.Logf("%v", err)
+	})
+}
+
+func TestFinalizer(t *testing.T) {
+	var buf bytes.Buffer
+	SetLogger(log.New(&buf, "", 0))
+	defer SetLogger(nil)
+
+	native := &testNative{version: "1.9.0", deleted: make(chan struct{}, 4)}
+	libPath := registerTestNative(t, native)
+
+	// an instance embedded in another struct, which is dropped without Delete
+	func() {
+		holder := &struct {
+			name      string
+			porcupine Porcupine
+		}{name: "leaked"}
+		holder.porcupine = Porcupine{LibraryPath: libPath, BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+		if err := holder.porcupine.Init(); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}()
+	waitForDelete := func(timeout time.Duration) bool {
+		deadline := time.After(timeout)
+		for {
+			runtime.GC()
+			select {
+			case <-native.deleted:
+				return true
+			case <-deadline:
+				return false
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}
+	if !waitForDelete(5 * time.Second) {
+		t.Fatalf("Expected the native engine of a garbage collected instance to be released.")
+	}
+	if !strings.Contains(buf.String(), "without calling Delete") {
+		t.Fatalf("Expected a warning to be logged, but got:\n%s", buf.String())
+	}
+
+	// instances released with Delete aren't released again
+	func() {
+		p := &Porcupine{LibraryPath: libPath, BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+		if err := p.Init(); err != nil {
+			t.Fatalf("%v", err)
+		}
+		p.Delete()
+		<-native.deleted
+	}()
+	if waitForDelete(200 * time.Millisecond) {
+		t.Fatalf("Expected the native engine of a deleted instance not to be released again.")
+	}
+}
+
+func TestClone(t *testing.T) {
+	requireNativeLibrary(t)
+
+	var uninitialized Porcupine
+	if _, err := uninitialized.Clone(); err == nil {
+		t.Fatalf("Expected an error cloning an uninitialized instance.")
+	}
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/multiple_keywords.wav")
+	pcm := readTestAudio(t, test_file)
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{ALEXA, PORCUPINE}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+	if err := p.SetSensitivities([]float32{0.6, 0.4}); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	clone, err := p.Clone()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer clone.Delete()
+	if clone.handle == p.handle {
+		t.Fatalf("Expected the clone to have its own native engine.")
+	}
+	if !reflect.DeepEqual(clone.Config(), p.Config()) {
+		t.Fatalf("Expected the clone to have configuration %v, but got %v", p.Config(), clone.Config())
+	}
+
+	// the original starts half a second earlier, so the streams are out of step
+	offset := SampleRate() / 2 / FrameLength() * FrameLength()
+	var results, cloneResults []int
+	for i := 0; i+FrameLength() <= len(pcm); i += FrameLength() {
+		keywordIndex, err := p.Process(pcm[i : i+FrameLength()])
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if keywordIndex >= 0 {
+			results = append(results, keywordIndex)
+		}
+		if i < offset {
+			continue
+		}
+		keywordIndex, err = clone.Process(pcm[i-offset : i-offset+FrameLength()])
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if keywordIndex >= 0 {
+			cloneResults = append(cloneResults, keywordIndex)
+		}
+	}
+	p.Delete()
+
+	for i := len(pcm)/FrameLength()*FrameLength() - offset; i+FrameLength() <= len(pcm); i += FrameLength() {
+		keywordIndex, err := clone.Process(pcm[i : i+FrameLength()])
+		if err != nil {
+			t.Fatalf("Expected the clone to keep processing after the original was deleted, but got %v", err)
+		}
+		if keywordIndex >= 0 {
+			cloneResults = append(cloneResults, keywordIndex)
+		}
+	}
+
+	expected := []int{1, 0, 1}
+	if !reflect.DeepEqual(results, expected) || !reflect.DeepEqual(cloneResults, expected) {
+		t.Fatalf("Expected keyword indices %v from both instances, but got %v and %v", expected, results, cloneResults)
+	}
+}
+
+func TestReset(t *testing.T) {
+	requireNativeLibrary(t)
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/porcupine.wav")
+	pcm := readTestAudio(t, test_file)
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}, MinDetectionGap: time.Hour}
+	err := p.Init()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	keywordIndices, err := p.ProcessBuffer(pcm)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(keywordIndices) != 1 {
+		t.Fatalf("Expected 1 detection before Reset, but got %v", keywordIndices)
+	}
+
+	if err := p.Reset(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if p.frameCount != 0 || len(p.RecentDetections()) != 0 || len(p.pendingPCM) != 0 {
+		t.Fatalf("Expected detection state to be cleared by Reset.")
+	}
+
+	// the cooldown was cleared, so the keyword is detected again
+	keywordIndices, err = p.ProcessBuffer(pcm)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(keywordIndices) != 1 || keywordIndices[0] != 0 {
+		t.Fatalf("Expected 1 detection after Reset, but got %v", keywordIndices)
+	}
+
+	var uninitialized Porcupine
+	if err := uninitialized.Reset(); err == nil {
+		t.Fatalf("Expected Reset without Init to fail.")
+	}
+}
+
+// Returns the resident set size of the test process in bytes, or skips the test where /proc is unavailable.
+func residentSetSize(t *testing.T) int64 {
+	rss := residentMemory()
+	if rss == 0 {
+		t.Skip("resident set size is unavailable")
+	}
+	return rss
+}
+
+func TestInitStats(t *testing.T) {
+	var uninitialized Porcupine
+	if stats := uninitialized.InitStats(); stats != (InitStats{}) {
+		t.Fatalf("Expected no stats before Init, but got %+v", stats)
+	}
+
+	native := &testNative{version: "1.9.0", initGate: make(chan struct{})}
+	libPath := registerTestNative(t, native)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(native.initGate)
+	}()
+
+	for _, timeout := range []time.Duration{0, time.Minute} {
+		p := Porcupine{LibraryPath: libPath, BuiltInKeywords: []BuiltInKeyword{PORCUPINE}, InitTimeout: timeout}
+		if err := p.Init(); err != nil {
+			t.Fatalf("%v", err)
+		}
+		stats := p.InitStats()
+		p.Delete()
+		if timeout == 0 && stats.NativeDuration < 20*time.Millisecond {
+			t.Fatalf("Expected the native init blocked for 20ms to be measured, but got %v", stats.NativeDuration)
+		}
+		if stats.NativeDuration <= 0 || stats.Duration < stats.NativeDuration {
+			t.Fatalf("Expected an init duration of at least the native duration, but got %+v", stats)
+		}
+	}
+
+	requireNativeLibrary(t)
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{ALEXA, PORCUPINE}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+	t.Logf("%+v", p.InitStats())
+}
+
+func TestInitDeleteMemory(t *testing.T) {
+	requireNativeLibrary(t)
+	if testing.Short() {
+		t.Skip("skipping init/delete loop in short mode")
+	}
+	if raceEnabled {
+		t.Skip("skipping memory measurement under the race detector")
+	}
+
+	// long paths make a leak of the C strings passed to the native init show up in the resident set size
+	dir := t.TempDir()
+	for i := 0; i < 8; i++ {
+		dir = filepath.Join(dir, strings.Repeat(string(rune('a'+i)), 200))
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("%v", err)
+	}
+	var keywordPaths []string
+	for _, keyword := range []BuiltInKeyword{ALEXA, PORCUPINE, TERMINATOR} {
+		data, err := ioutil.ReadFile(testKeywordFile(t, keyword))
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		keywordPath := filepath.Join(dir, filepath.Base(testKeywordFile(t, keyword)))
+		if err := ioutil.WriteFile(keywordPath, data, 0644); err != nil {
+			t.Fatalf("%v", err)
+		}
+		keywordPaths = append(keywordPaths, keywordPath)
+	}
+
+	initDelete := func(n int) {
+		for i := 0; i < n; i++ {
+			p := Porcupine{KeywordPaths: keywordPaths}
+			if err := p.Init(); err != nil {
+				t.Fatalf("%v", err)
+			}
+			p.Delete()
+		}
+		// return freed Go heap to the OS so that only native memory counts toward the resident set size
+		debug.FreeOSMemory()
+	}
+
+	// warm up allocator pools and the native library before measuring
+	initDelete(200)
+
+	// leaking the keyword paths alone would add 3 * ~1.6KB per iteration, so a leak grows by more than 2KB per
+	// iteration in every round while allocator noise does not
+	const iterations = 500
+	var growths []int64
+	for round := 0; round < 3; round++ {
+		before := residentSetSize(t)
+		initDelete(iterations)
+		growth := residentSetSize(t) - before
+		if growth < iterations*2048 {
+			return
+		}
+		growths = append(growths, growth)
+	}
+	t.Fatalf("Expected memory use to stay flat across init and delete, but it grew by %v bytes per %d iterations",
+		growths, iterations)
+}
+
+func TestInitTwice(t *testing.T) {
+	native := &testNative{version: "1.9.0", processResults: []int{0}}
+	p := Porcupine{LibraryPath: registerTestNative(t, native), BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	// a second Init that reached the native library would fail with OUT_OF_MEMORY
+	native.initStatus = OUT_OF_MEMORY
+	err := p.Init()
+	var porcupineErr *PorcupineError
+	if !errors.As(err, &porcupineErr) || porcupineErr.StatusCode != INVALID_STATE {
+		t.Fatalf("Expected INVALID_STATE initializing a live instance, but got %v", err)
+	}
+	if keywordIndex, err := p.Process(make([]int16, FrameLength())); err != nil || keywordIndex != 0 {
+		t.Fatalf("Expected the existing engine to stay in use, but got %d, %v", keywordIndex, err)
+	}
+
+	native.initStatus = SUCCESS
+	p.Delete()
+	if err := p.Init(); err != nil {
+		t.Fatalf("Expected Init to succeed after Delete, but got %v", err)
+	}
+}
+
+func TestInitTimeout(t *testing.T) {
+	native := &testNative{version: "1.9.0", initGate: make(chan struct{}), deleted: make(chan struct{}, 1)}
+	libPath := registerTestNative(t, native)
+
+	p := Porcupine{LibraryPath: libPath, BuiltInKeywords: []BuiltInKeyword{PORCUPINE}, InitTimeout: 10 * time.Millisecond}
+	err := p.Init()
+	var porcupineErr *PorcupineError
+	if !errors.As(err, &porcupineErr) || porcupineErr.StatusCode != RUNTIME_ERROR {
+		t.Fatalf("Expected a timeout error, but got: %v", err)
+	}
+	if _, err := p.Process(make([]int16, 512)); err == nil {
+		t.Fatalf("Expected Process to fail after Init timed out.")
+	}
+
+	// the abandoned init completes in the background and releases its engine
+	close(native.initGate)
+	select {
+	case <-native.deleted:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the engine of the abandoned init to be deleted.")
+	}
+
+	p.InitTimeout = time.Minute
+	if err := p.Init(); err != nil {
+		t.Fatalf("Expected Init within the timeout to succeed, but got: %v", err)
+	}
+	if p.NumKeywords() != 1 || p.Keywords()[0] != "porcupine" {
+		t.Fatalf("Expected the engine to be set up with the configured keywords, but got %v", p.Keywords())
+	}
+	if _, err := p.Process(make([]int16, 512)); err != nil {
+		t.Fatalf("%v", err)
+	}
+	p.Delete()
+}
+
+func TestSetKeywordEnabled(t *testing.T) {
+	requireNativeLibrary(t)
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/multiple_keywords.wav")
+	pcm := readTestAudio(t, test_file)
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{ALEXA, PORCUPINE}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	process := func() []int {
+		var results []int
+		for i := 0; i+FrameLength() <= len(pcm); i += FrameLength() {
+			keywordIndex, err := p.Process(pcm[i : i+FrameLength()])
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			if keywordIndex >= 0 {
+				results = append(results, keywordIndex)
+			}
+		}
+		return results
+	}
+
+	p.SetKeywordEnabled(0, false)
+	p.SetKeywordEnabled(5, false)
+	if results := process(); !reflect.DeepEqual(results, []int{1, 1}) {
+		t.Fatalf("Expected keyword indices [1 1] with 'alexa' disabled, but got %v", results)
+	}
+	for _, detection := range p.RecentDetections() {
+		if detection.Index == 0 {
+			t.Fatalf("Expected no detections of disabled 'alexa' to be recorded, but got %v", detection)
+		}
+	}
+
+	// Reset restarts the stream but keeps the mask
+	if err := p.Reset(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if results := process(); !reflect.DeepEqual(results, []int{1, 1}) {
+		t.Fatalf("Expected keyword indices [1 1] after Reset, but got %v", results)
+	}
+
+	p.SetKeywordEnabled(0, true)
+	if err := p.Reset(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if results := process(); !reflect.DeepEqual(results, []int{1, 0, 1}) {
+		t.Fatalf("Expected keyword indices [1 0 1] with 'alexa' enabled again, but got %v", results)
+	}
+}
+
+func TestMinDetectionGap(t *testing.T) {
+	// a single utterance detected in three consecutive frames, then again four frames (128ms) after the first
+	results := []int{0, 0, 0, -1, 0}
+
+	detect := func(opts ...Option) []int {
+		native := &testNative{version: "1.9.0", processResults: append([]int(nil), results...)}
+		p := &Porcupine{LibraryPath: registerTestNative(t, native), BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+		for _, opt := range opts {
+			opt(p)
+		}
+		if err := p.Init(); err != nil {
+			t.Fatalf("%v", err)
+		}
+		defer p.Delete()
+
+		var detected []int
+		for i := range results {
+			keywordIndex, err := p.Process(make([]int16, 512))
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			if keywordIndex >= 0 {
+				detected = append(detected, i)
+			}
+		}
+		return detected
+	}
+
+	if detected := detect(); !reflect.DeepEqual(detected, []int{0, 1, 2, 4}) {
+		t.Fatalf("Expected every detection to be reported by default, but got detections in frames %v", detected)
+	}
+	if detected := detect(WithMinDetectionGap(100 * time.Millisecond)); !reflect.DeepEqual(detected, []int{0, 4}) {
+		t.Fatalf("Expected repeats within 100ms to be suppressed, but got detections in frames %v", detected)
+	}
+}
+
+func TestMissingSymbol(t *testing.T) {
+	// stub of an incompatible library that exports everything but pv_porcupine_delete
+	libPath := buildStubLibrary(t, `
+int pv_porcupine_init(void) { return 0; }
+int pv_porcupine_process(void) { return 0; }
+int pv_sample_rate(void) { return 16000; }
+const char *pv_porcupine_version(void) { return "1.9.0"; }
+int pv_porcupine_frame_length(void) { return 512; }
+`)
+
+	p := Porcupine{LibraryPath: libPath, BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	err := p.Init()
+	var symbolErr *MissingSymbolError
+	if !errors.As(err, &symbolErr) {
+		t.Fatalf("Expected MissingSymbolError, but got %v", err)
+	}
+	if symbolErr.Name != "pv_porcupine_delete" || symbolErr.LibraryPath != libPath {
+		t.Fatalf("Expected missing pv_porcupine_delete in %s, but got %s in %s",
+			libPath, symbolErr.Name, symbolErr.LibraryPath)
+	}
+	if _, err := p.Process(make([]int16, 512)); err == nil {
+		t.Fatalf("Expected Process to fail after Init failed.")
+	}
+	t.Logf("%v", err)
+}
+
+func TestIsAvailable(t *testing.T) {
+	if available, err := IsAvailable(); !available || err != nil {
+		t.Fatalf("Expected the library to be available, but got %v, %v", available, err)
+	}
+
+	nativePorcupineMutex.Lock()
+	previousPath, previousLibrary := defaultLibraryPath, nativePorcupine
+	nativePorcupineMutex.Unlock()
+	t.Cleanup(func() {
+		nativePorcupineMutex.Lock()
+		defaultLibraryPath, nativePorcupine, nativePorcupineErr = previousPath, previousLibrary, nil
+		nativePorcupineMutex.Unlock()
+	})
+	useDefaultLibrary := func(libPath string) {
+		nativePorcupineMutex.Lock()
+		defaultLibraryPath, nativePorcupine, nativePorcupineErr = libPath, nil, nil
+		nativePorcupineMutex.Unlock()
+	}
+
+	useDefaultLibrary("/does/not/exist.so")
+	if available, err := IsAvailable(); available || err == nil {
+		t.Fatalf("Expected a missing library to be unavailable, but got %v, %v", available, err)
+	}
+
+	// library that exports everything but pv_porcupine_delete
+	useDefaultLibrary(buildStubLibrary(t, `
+int pv_porcupine_init(void) { return 0; }
+int pv_porcupine_process(void) { return 0; }
+int pv_sample_rate(void) { return 16000; }
+const char *pv_porcupine_version(void) { return "1.9.0"; }
+int pv_porcupine_frame_length(void) { return 512; }
+`))
+	available, err := IsAvailable()
+	var symbolErr *MissingSymbolError
+	if available || !errors.As(err, &symbolErr) {
+		t.Fatalf("Expected an incompatible library to be unavailable with MissingSymbolError, but got %v, %v",
+			available, err)
+	}
+}
+
+func TestLibraryLoadFlags(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("relies on unresolved symbols being allowed in shared libraries")
+	}
+
+	// stub that calls a function no loaded library defines, so it only loads with lazy binding
+	source := `
+extern int undefined_dependency(void);
+int pv_porcupine_init(void) { return 0; }
+int pv_porcupine_process(void) { return undefined_dependency(); }
+int pv_sample_rate(void) { return 16000; }
+const char *pv_porcupine_version(void) { return "1.9.0"; }
+int pv_porcupine_frame_length(void) { return 512; }
+void pv_porcupine_delete(void) {}
+`
+	t.Cleanup(func() { SetLibraryLoadFlags(LOAD_NOW) })
+
+	if _, err := getNativeLibrary(buildStubLibrary(t, source)); err == nil {
+		t.Fatalf("Expected loading a library with unresolved symbols to fail with LOAD_NOW.")
+	}
+
+	SetLibraryLoadFlags(LOAD_LAZY | LOAD_GLOBAL)
+	library, err := getNativeLibrary(buildStubLibrary(t, source))
+	if err != nil {
+		t.Fatalf("Expected the library to load with LOAD_LAZY, but got %v", err)
+	}
+	if library.nativeVersion() != "1.9.0" {
+		t.Fatalf("Expected version 1.9.0, but got %s", library.nativeVersion())
+	}
+}
+
+// Compiles C source into a shared library with the C compiler, skipping the test if that isn't possible.
+func buildStubLibrary(t *testing.T, source string) string {
+	t.Helper()
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("builds a stub shared library with the C compiler")
+	}
+	cc, err := exec.LookPath("cc")
+	if err != nil {
+		t.Skip("requires a C compiler to build a stub library")
+	}
+
+	dir := t.TempDir()
+	stubSource := filepath.Join(dir, "stub.c")
+	if err := ioutil.WriteFile(stubSource, []byte(source), 0644); err != nil {
+		t.Fatalf("%v", err)
+	}
+	libPath := filepath.Join(dir, "libpv_porcupine_stub.so")
+	if out, err := exec.Command(cc, "-shared", "-fPIC", "-o", libPath, stubSource).CombinedOutput(); err != nil {
+		t.Skipf("could not build stub library: %v\n%s", err, out)
+	}
+	return libPath
+}
+
+func TestDisableAutoExtraction(t *testing.T) {
+	modelPath := copyTestFile(t, testModelFile(t))
+	keywordPath := copyTestFile(t, testKeywordFile(t, PORCUPINE))
+	libPath := registerTestNative(t, &testNative{version: "1.9.0"})
+
+	previousDir := getExtractionDir()
+	dir := t.TempDir()
+	t.Cleanup(func() {
+		SetAutoExtraction(true)
+		if err := SetExtractionDir(previousDir); err != nil {
+			t.Fatalf("%v", err)
+		}
+	})
+	if err := SetExtractionDir(dir); err != nil {
+		t.Fatalf("%v", err)
+	}
+	SetAutoExtraction(false)
+
+	tests := []struct {
+		porcupine *Porcupine
+		expected  string
+	}{
+		{&Porcupine{}, "LibraryPath, ModelPath, KeywordPaths must be set"},
+		{&Porcupine{LibraryPath: libPath, KeywordPaths: []string{keywordPath}}, "ModelPath must be set"},
+		{&Porcupine{LibraryPath: libPath, ModelPath: modelPath}, "KeywordPaths must be set"},
+		{&Porcupine{LibraryPath: libPath, ModelPath: modelPath, BuiltInKeywords: []BuiltInKeyword{PORCUPINE}},
+			"BuiltInKeywords can't be used"},
+		{&Porcupine{LibraryPath: libPath, ModelPath: modelPath, KeywordSet: NewKeywordSet().AddBuiltIn(ALEXA, 0.5)},
+			"built-in keywords in KeywordSet can't be used"},
+	}
+	for _, tt := range tests {
+		err := tt.porcupine.Init()
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Fatalf("Expected an error containing '%s', but got: %v", tt.expected, err)
+		}
+	}
+
+	if FrameLength() != 0 && os.Getenv("PORCUPINE_LIBRARY_PATH") == "" {
+		t.Fatalf("Expected the default library not to be extracted.")
+	}
+
+	p := Porcupine{LibraryPath: libPath, ModelPath: modelPath, KeywordPaths: []string{keywordPath}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("Expected Init with provisioned files to succeed, but got: %v", err)
+	}
+	p.Delete()
+
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Fatalf("Expected nothing to be extracted, but found %d files in '%s'", len(files), dir)
+	}
+}
+
+func TestConfigConcurrentWithSetSensitivities(t *testing.T) {
+	native := &testNative{version: "1.9.0"}
+	p := Porcupine{LibraryPath: registerTestNative(t, native), BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	// run with -race to check that the accessors don't race with the configuration updated by SetSensitivities
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			if err := p.SetSensitivities([]float32{float32(i%10) / 10}); err != nil {
+				t.Errorf("%v", err)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		if config := p.Config(); len(config.Sensitivities) != 1 {
+			t.Fatalf("Expected 1 sensitivity, but got %v", config.Sensitivities)
+		}
+		if len(p.Keywords()) != p.NumKeywords() {
+			t.Fatalf("Expected %d keywords, but got %v", p.NumKeywords(), p.Keywords())
+		}
+	}
+	<-done
+}
+
+func TestConfig(t *testing.T) {
+	var uninitialized Porcupine
+	if config := uninitialized.Config(); config.ModelPath != "" || config.Keywords != nil {
+		t.Fatalf("Expected an empty configuration before Init, but got %+v", config)
+	}
+
+	p := Porcupine{AccessKey: "secret", BuiltInKeywords: []BuiltInKeyword{PORCUPINE}, Sensitivities: []float32{0.7}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	config := p.Config()
+	if config.LibraryPath != defaultLibraryFile() || config.LibraryVersion != Version() {
+		t.Fatalf("Expected library %s (%s), but got %s (%s)", defaultLibraryFile(), Version(),
+			config.LibraryPath, config.LibraryVersion)
+	}
+	if config.ModelPath != testModelFile(t) {
+		t.Fatalf("Expected model %s, but got %s", testModelFile(t), config.ModelPath)
+	}
+	if config.FrameLength != FrameLength() {
+		t.Fatalf("Expected a frame length of %d, but got %d", FrameLength(), config.FrameLength)
+	}
+	if !reflect.DeepEqual(config.Keywords, []string{"porcupine"}) ||
+		!reflect.DeepEqual(config.KeywordPaths, []string{testKeywordFile(t, PORCUPINE)}) ||
+		!reflect.DeepEqual(config.Sensitivities, []float32{0.7}) {
+		t.Fatalf("Unexpected keywords in %+v", config)
+	}
+
+	line := config.String()
+	if strings.Contains(line, "secret") || !strings.Contains(line, "access key redacted") {
+		t.Fatalf("Expected the AccessKey to be redacted, but got: %s", line)
+	}
+	if formatted := fmt.Sprintf("%+v", config); formatted != line {
+		t.Fatalf("Expected formatting to use String, but got: %s", formatted)
+	}
+	t.Logf("%v", config)
+}
+
+func TestProcessContext(t *testing.T) {
+	native := &testNative{version: "1.9.0", processResults: []int{-1, 0}, processGate: make(chan struct{})}
+	p := Porcupine{LibraryPath: registerTestNative(t, native), BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.ProcessContext(cancelled, make([]int16, 512)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, but got: %v", err)
+	}
+
+	// the native call hangs until the gate is closed
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	keywordIndex, err := p.ProcessContext(ctx, make([]int16, 512))
+	if !errors.Is(err, context.DeadlineExceeded) || keywordIndex != -1 {
+		t.Fatalf("Expected context.DeadlineExceeded, but got %d, %v", keywordIndex, err)
+	}
+
+	close(native.processGate)
+	keywordIndex, err = p.ProcessContext(context.Background(), make([]int16, 512))
+	if err != nil || keywordIndex != 0 {
+		t.Fatalf("Expected the next frame to be processed once the native call returned, but got %d, %v",
+			keywordIndex, err)
+	}
+	if _, err := p.ProcessContext(context.Background(), make([]int16, 511)); err == nil {
+		t.Fatalf("Expected an error for a frame of the wrong size.")
+	}
+}
+
+func TestVersionedExtractionDir(t *testing.T) {
+	files := fstest.MapFS{"embedded/lib/libpv_porcupine.so": {Data: []byte("library")}}
+	changedFiles := fstest.MapFS{"embedded/lib/libpv_porcupine.so": {Data: []byte("other library")}}
+
+	if versionDirName("1.9.0", files) != versionDirName("1.9.0", files) {
+		t.Fatalf("Expected the same files to be extracted to the same directory.")
+	}
+	names := map[string]bool{
+		versionDirName("1.9.0", files):        true,
+		versionDirName("2.0.0", files):        true,
+		versionDirName("1.9.0", changedFiles): true,
+	}
+	if len(names) != 3 {
+		t.Fatalf("Expected different versions and files to be extracted to different directories, but got %v", names)
+	}
+	for name := range names {
+		if !versionDirPattern.MatchString(name) {
+			t.Fatalf("Expected '%s' to be recognized as a version directory.", name)
+		}
+	}
+
+	previousDir := getExtractionDir()
+	dir := t.TempDir()
+	t.Cleanup(func() {
+		if err := SetExtractionDir(previousDir); err != nil {
+			t.Fatalf("%v", err)
+		}
+	})
+	if err := SetExtractionDir(dir); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+	if !strings.HasPrefix(p.modelPath, filepath.Join(dir, embeddedVersionDir)+string(filepath.Separator)) {
+		t.Fatalf("Expected model to be extracted to '%s', but got '%s'", filepath.Join(dir, embeddedVersionDir),
+			p.modelPath)
+	}
+
+	staleDir := filepath.Join(dir, versionDirName("1.8.0", files))
+	keptDirs := []string{filepath.Join(dir, "data"), filepath.Join(dir, "unrelated"), filepath.Join(dir, embeddedVersionDir)}
+	for _, d := range append(keptDirs, staleDir) {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+	if err := PruneExtractionDirs(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if _, err := os.Stat(staleDir); !os.IsNotExist(err) {
+		t.Fatalf("Expected '%s' to be pruned, but got: %v", staleDir, err)
+	}
+	for _, d := range keptDirs {
+		if _, err := os.Stat(d); err != nil {
+			t.Fatalf("Expected '%s' to be kept, but got: %v", d, err)
+		}
+	}
+}
+
+func TestPreload(t *testing.T) {
+	previousDir := getExtractionDir()
+	dir := t.TempDir()
+	t.Cleanup(func() {
+		if err := SetExtractionDir(previousDir); err != nil {
+			t.Fatalf("%v", err)
+		}
+	})
+	if err := SetExtractionDir(dir); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if err := Preload(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	for _, path := range []string{defaultModelFile, builtinKeywords[string(PORCUPINE)]} {
+		if !strings.HasPrefix(path, dir) {
+			t.Fatalf("Expected '%s' to be extracted to '%s' by Preload.", path, dir)
+		}
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+	if FrameLength() == 0 {
+		t.Fatalf("Expected the default library to be loaded by Preload, but got: %v", LibraryError())
+	}
+	if err := Preload(); err != nil {
+		t.Fatalf("Expected a second Preload to succeed, but got: %v", err)
+	}
+}
+
+func TestProcessInvalidNativeIndex(t *testing.T) {
+	native := &testNative{version: "1.9.0", processResults: []int{1, -2, 0}}
+	p := Porcupine{LibraryPath: registerTestNative(t, native), BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	for i := 0; i < 2; i++ {
+		if keywordIndex, err := p.Process(make([]int16, 512)); err == nil || keywordIndex != -1 {
+			t.Fatalf("Expected an error for a keyword index out of range, but got %d, %v", keywordIndex, err)
+		}
+	}
+	if keywordIndex, err := p.Process(make([]int16, 512)); err != nil || keywordIndex != 0 {
+		t.Fatalf("Expected a detection, but got %d, %v", keywordIndex, err)
 	}
 }
@@ -11,8 +11,8 @@
 
 // Go binding for Porcupine wake word engine. It detects utterances of given keywords within an incoming stream of
 // audio in real-time. It processes incoming audio in consecutive frames and for each frame emits the detection result.
-// The number of samples per frame can be attained by calling `.FrameLength`. The incoming audio needs to have a
-// sample rate equal to `.SampleRate` and be 16-bit linearly-encoded. Porcupine operates on single-channel audio.
+// The number of samples per frame can be attained by calling `FrameLength()`. The incoming audio needs to have a
+// sample rate equal to `SampleRate()` and be 16-bit linearly-encoded. Porcupine operates on single-channel audio.
 
 // +build windows
 
@@ -22,70 +22,134 @@ package porcupine
 import "C"
 
 import (
+	"fmt"
 	"unsafe"
 
 	"golang.org/x/sys/windows"
 )
 
-// private vars
-var (
-	lib               = windows.NewLazyDLL(libName)
-	init_func         = lib.NewProc("pv_porcupine_init")
-	process_func      = lib.NewProc("pv_porcupine_process")
-	sample_rate_func  = lib.NewProc("pv_sample_rate")
-	version_func      = lib.NewProc("pv_porcupine_version")
-	frame_length_func = lib.NewProc("pv_porcupine_frame_length")
-	delete_func       = lib.NewProc("pv_porcupine_delete")
-)
+// native library loaded with LoadLibrary
+type nativePorcupineType struct {
+	lib               *windows.LazyDLL
+	init_func         *windows.LazyProc
+	process_func      *windows.LazyProc
+	sample_rate_func  *windows.LazyProc
+	version_func      *windows.LazyProc
+	frame_length_func *windows.LazyProc
+	delete_func       *windows.LazyProc
+
+	// only available in Porcupine 2.0 and later
+	get_error_stack_func  *windows.LazyProc
+	free_error_stack_func *windows.LazyProc
+}
+
+func loadNativeLibrary(libPath string) (*nativePorcupineType, error) {
+	lib := windows.NewLazyDLL(libPath)
+	if err := lib.Load(); err != nil {
+		return nil, fmt.Errorf("Failed to load Porcupine library at %s: %v", libPath, err)
+	}
+
+	np := &nativePorcupineType{
+		lib:               lib,
+		init_func:         lib.NewProc("pv_porcupine_init"),
+		process_func:      lib.NewProc("pv_porcupine_process"),
+		sample_rate_func:  lib.NewProc("pv_sample_rate"),
+		version_func:      lib.NewProc("pv_porcupine_version"),
+		frame_length_func: lib.NewProc("pv_porcupine_frame_length"),
+		delete_func:       lib.NewProc("pv_porcupine_delete"),
+
+		get_error_stack_func:  lib.NewProc("pv_get_error_stack"),
+		free_error_stack_func: lib.NewProc("pv_free_error_stack"),
+	}
+
+	required := []*windows.LazyProc{
+		np.init_func, np.process_func, np.sample_rate_func, np.version_func, np.frame_length_func, np.delete_func,
+	}
+	for _, proc := range required {
+		if err := proc.Find(); err != nil {
+			return nil, &MissingSymbolError{Name: proc.Name, LibraryPath: libPath}
+		}
+	}
+	return np, nil
+}
 
-func (np nativePorcupineType) nativeInit(porcupine *Porcupine) (status PvStatus) {
+func (np *nativePorcupineType) nativeInit(porcupine *Porcupine) (status PvStatus) {
 	var (
-		modelPathC  = C.CString(porcupine.ModelPath)
-		numKeywords = len(porcupine.KeywordPaths)
+		modelPathC  = C.CString(porcupine.modelPath)
+		numKeywords = len(porcupine.keywordPaths)
 		keywordsC   = make([]*C.char, numKeywords)
 	)
 	defer C.free(unsafe.Pointer(modelPathC))
 
-	for i, s := range porcupine.KeywordPaths {
+	for i, s := range porcupine.keywordPaths {
 		keywordsC[i] = C.CString(s)
 		defer C.free(unsafe.Pointer(keywordsC[i]))
 	}
 
-	ret, _, _ := init_func.Call(
+	args := []uintptr{
 		uintptr(unsafe.Pointer(modelPathC)),
 		uintptr(numKeywords),
 		uintptr(unsafe.Pointer(&keywordsC[0])),
-		uintptr(unsafe.Pointer(&porcupine.Sensitivities[0])),
-		uintptr(unsafe.Pointer(&porcupine.handle)))
+		uintptr(unsafe.Pointer(&porcupine.sensitivities[0])),
+		uintptr(unsafe.Pointer(&porcupine.handle)),
+	}
+	if requiresAccessKey(np.nativeVersion()) {
+		accessKeyC := C.CString(porcupine.AccessKey)
+		defer C.free(unsafe.Pointer(accessKeyC))
+		args = append([]uintptr{uintptr(unsafe.Pointer(accessKeyC))}, args...)
+	}
+
+	ret, _, _ := np.init_func.Call(args...)
 
 	return PvStatus(ret)
 }
 
-func (np nativePorcupineType) nativeDelete(porcupine *Porcupine) {
-	delete_func.Call(porcupine.handle)
+func (np *nativePorcupineType) nativeDelete(porcupine *Porcupine) {
+	np.delete_func.Call(uintptr(porcupine.handle))
 }
 
-func (np nativePorcupineType) nativeProcess(porcupine *Porcupine, pcm []int16) (status PvStatus, keywordIndex int) {
+func (np *nativePorcupineType) nativeProcess(porcupine *Porcupine, pcm []int16) (status PvStatus, keywordIndex int) {
 
-	var index int32
-	ret, _, _ := process_func.Call(
-		porcupine.handle,
+	ret, _, _ := np.process_func.Call(
+		uintptr(porcupine.handle),
 		uintptr(unsafe.Pointer(&pcm[0])),
-		uintptr(unsafe.Pointer(&index)))
-	return PvStatus(ret), int(index)
+		uintptr(unsafe.Pointer(&porcupine.nativeKeywordIndex)))
+	return PvStatus(ret), int(porcupine.nativeKeywordIndex)
 }
 
-func (np nativePorcupineType) nativeSampleRate() (sampleRate int) {
-	ret, _, _ := sample_rate_func.Call()
+func (np *nativePorcupineType) nativeSampleRate() (sampleRate int) {
+	ret, _, _ := np.sample_rate_func.Call()
 	return int(ret)
 }
 
-func (np nativePorcupineType) nativeFrameLength() (frameLength int) {
-	ret, _, _ := frame_length_func.Call()
+func (np *nativePorcupineType) nativeFrameLength() (frameLength int) {
+	ret, _, _ := np.frame_length_func.Call()
 	return int(ret)
 }
 
-func (np nativePorcupineType) nativeVersion() (version string) {
-	ret, _, _ := version_func.Call()
+func (np *nativePorcupineType) nativeVersion() (version string) {
+	ret, _, _ := np.version_func.Call()
 	return C.GoString((*C.char)(unsafe.Pointer(ret)))
 }
+
+func (np *nativePorcupineType) nativeErrorStack() (messages []string) {
+	if np.get_error_stack_func.Find() != nil || np.free_error_stack_func.Find() != nil {
+		return nil
+	}
+
+	var messageStack **C.char
+	var messageStackDepth int32
+	ret, _, _ := np.get_error_stack_func.Call(
+		uintptr(unsafe.Pointer(&messageStack)),
+		uintptr(unsafe.Pointer(&messageStackDepth)))
+	if PvStatus(ret) != SUCCESS || messageStack == nil {
+		return nil
+	}
+	defer np.free_error_stack_func.Call(uintptr(unsafe.Pointer(messageStack)))
+
+	stack := (*[1 << 20]*C.char)(unsafe.Pointer(messageStack))[:messageStackDepth:messageStackDepth]
+	for _, message := range stack {
+		messages = append(messages, C.GoString(message))
+	}
+	return messages
+}
@@ -0,0 +1,47 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package porcupine
+
+import (
+	"errors"
+	"io/fs"
+	"runtime"
+	"testing"
+)
+
+// Windows architectures with a bundled library. 32-bit (386) builds are supported only if copy.sh bundled an x86
+// library; other architectures are not supported.
+var supportedWindowsArches = map[string]string{
+	"amd64": "embedded/lib/windows/amd64/libpv_porcupine.dll",
+}
+
+func TestWindowsArch(t *testing.T) {
+	libPath, err := embeddedLibraryFile()
+	expected, ok := supportedWindowsArches[runtime.GOARCH]
+	if x86Lib := "embedded/lib/windows/x86/libpv_porcupine.dll"; runtime.GOARCH == "386" {
+		if _, err := fs.Stat(embeddedFS, x86Lib); err == nil {
+			expected, ok = x86Lib, true
+		}
+	}
+	if !ok {
+		var platformErr *UnsupportedPlatformError
+		if !errors.As(err, &platformErr) {
+			t.Fatalf("Expected UnsupportedPlatformError for windows/%s, but got %v", runtime.GOARCH, err)
+		}
+		return
+	}
+	if err != nil || libPath != expected {
+		t.Fatalf("Expected library %s for windows/%s, but got '%s' (%v)", expected, runtime.GOARCH, libPath, err)
+	}
+}
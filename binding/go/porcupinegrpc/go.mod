@@ -0,0 +1,11 @@
+module github.com/Picovoice/porcupine/binding/go/porcupinegrpc
+
+go 1.16
+
+require (
+	github.com/Picovoice/porcupine/binding/go v1.9.0
+	google.golang.org/grpc v1.46.2
+	google.golang.org/protobuf v1.28.1
+)
+
+replace github.com/Picovoice/porcupine/binding/go => ../
@@ -0,0 +1,241 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        (unknown)
+// source: porcupine.proto
+
+package porcupinegrpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// A chunk of single-channel, 16-bit, little-endian PCM at the engine's sample rate. Chunks do not need to align
+// with the engine's frame length.
+type AudioChunk struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Pcm []byte `protobuf:"bytes,1,opt,name=pcm,proto3" json:"pcm,omitempty"`
+}
+
+func (x *AudioChunk) Reset() {
+	*x = AudioChunk{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_porcupine_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AudioChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AudioChunk) ProtoMessage() {}
+
+func (x *AudioChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_porcupine_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AudioChunk.ProtoReflect.Descriptor instead.
+func (*AudioChunk) Descriptor() ([]byte, []int) {
+	return file_porcupine_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AudioChunk) GetPcm() []byte {
+	if x != nil {
+		return x.Pcm
+	}
+	return nil
+}
+
+// A keyword detected in the stream.
+type DetectionEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// 0 based index of the detected keyword in the server's keyword configuration.
+	KeywordIndex int32 `protobuf:"varint,1,opt,name=keyword_index,json=keywordIndex,proto3" json:"keyword_index,omitempty"`
+	// Index of the first sample after the frame in which the keyword was detected, counted from the start of the
+	// stream.
+	SampleOffset int64 `protobuf:"varint,2,opt,name=sample_offset,json=sampleOffset,proto3" json:"sample_offset,omitempty"`
+}
+
+func (x *DetectionEvent) Reset() {
+	*x = DetectionEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_porcupine_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DetectionEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DetectionEvent) ProtoMessage() {}
+
+func (x *DetectionEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_porcupine_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DetectionEvent.ProtoReflect.Descriptor instead.
+func (*DetectionEvent) Descriptor() ([]byte, []int) {
+	return file_porcupine_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *DetectionEvent) GetKeywordIndex() int32 {
+	if x != nil {
+		return x.KeywordIndex
+	}
+	return 0
+}
+
+func (x *DetectionEvent) GetSampleOffset() int64 {
+	if x != nil {
+		return x.SampleOffset
+	}
+	return 0
+}
+
+var File_porcupine_proto protoreflect.FileDescriptor
+
+var file_porcupine_proto_rawDesc = []byte{
+	0x0a, 0x0f, 0x70, 0x6f, 0x72, 0x63, 0x75, 0x70, 0x69, 0x6e, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x12, 0x09, 0x70, 0x6f, 0x72, 0x63, 0x75, 0x70, 0x69, 0x6e, 0x65, 0x22, 0x1e, 0x0a, 0x0a,
+	0x41, 0x75, 0x64, 0x69, 0x6f, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x12, 0x10, 0x0a, 0x03, 0x70, 0x63,
+	0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x70, 0x63, 0x6d, 0x22, 0x5a, 0x0a, 0x0e,
+	0x44, 0x65, 0x74, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x23,
+	0x0a, 0x0d, 0x6b, 0x65, 0x79, 0x77, 0x6f, 0x72, 0x64, 0x5f, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0c, 0x6b, 0x65, 0x79, 0x77, 0x6f, 0x72, 0x64, 0x49, 0x6e,
+	0x64, 0x65, 0x78, 0x12, 0x23, 0x0a, 0x0d, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x5f, 0x6f, 0x66,
+	0x66, 0x73, 0x65, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x73, 0x61, 0x6d, 0x70,
+	0x6c, 0x65, 0x4f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x32, 0x4b, 0x0a, 0x09, 0x50, 0x6f, 0x72, 0x63,
+	0x75, 0x70, 0x69, 0x6e, 0x65, 0x12, 0x3e, 0x0a, 0x06, 0x44, 0x65, 0x74, 0x65, 0x63, 0x74, 0x12,
+	0x15, 0x2e, 0x70, 0x6f, 0x72, 0x63, 0x75, 0x70, 0x69, 0x6e, 0x65, 0x2e, 0x41, 0x75, 0x64, 0x69,
+	0x6f, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x1a, 0x19, 0x2e, 0x70, 0x6f, 0x72, 0x63, 0x75, 0x70, 0x69,
+	0x6e, 0x65, 0x2e, 0x44, 0x65, 0x74, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x28, 0x01, 0x30, 0x01, 0x42, 0x39, 0x5a, 0x37, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e,
+	0x63, 0x6f, 0x6d, 0x2f, 0x50, 0x69, 0x63, 0x6f, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x2f, 0x70, 0x6f,
+	0x72, 0x63, 0x75, 0x70, 0x69, 0x6e, 0x65, 0x2f, 0x62, 0x69, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x2f,
+	0x67, 0x6f, 0x2f, 0x70, 0x6f, 0x72, 0x63, 0x75, 0x70, 0x69, 0x6e, 0x65, 0x67, 0x72, 0x70, 0x63,
+	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_porcupine_proto_rawDescOnce sync.Once
+	file_porcupine_proto_rawDescData = file_porcupine_proto_rawDesc
+)
+
+func file_porcupine_proto_rawDescGZIP() []byte {
+	file_porcupine_proto_rawDescOnce.Do(func() {
+		file_porcupine_proto_rawDescData = protoimpl.X.CompressGZIP(file_porcupine_proto_rawDescData)
+	})
+	return file_porcupine_proto_rawDescData
+}
+
+var file_porcupine_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_porcupine_proto_goTypes = []interface{}{
+	(*AudioChunk)(nil),     // 0: porcupine.AudioChunk
+	(*DetectionEvent)(nil), // 1: porcupine.DetectionEvent
+}
+var file_porcupine_proto_depIdxs = []int32{
+	0, // 0: porcupine.Porcupine.Detect:input_type -> porcupine.AudioChunk
+	1, // 1: porcupine.Porcupine.Detect:output_type -> porcupine.DetectionEvent
+	1, // [1:2] is the sub-list for method output_type
+	0, // [0:1] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_porcupine_proto_init() }
+func file_porcupine_proto_init() {
+	if File_porcupine_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_porcupine_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AudioChunk); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_porcupine_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DetectionEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_porcupine_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_porcupine_proto_goTypes,
+		DependencyIndexes: file_porcupine_proto_depIdxs,
+		MessageInfos:      file_porcupine_proto_msgTypes,
+	}.Build()
+	File_porcupine_proto = out.File
+	file_porcupine_proto_rawDesc = nil
+	file_porcupine_proto_goTypes = nil
+	file_porcupine_proto_depIdxs = nil
+}
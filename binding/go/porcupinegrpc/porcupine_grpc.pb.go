@@ -0,0 +1,155 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: porcupine.proto
+
+package porcupinegrpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Porcupine_Detect_FullMethodName = "/porcupine.Porcupine/Detect"
+)
+
+// PorcupineClient is the client API for Porcupine service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type PorcupineClient interface {
+	// Streams audio to an engine from the server's pool and receives an event for every detected keyword. The
+	// stream holds on to the engine until the client closes its side of the stream or the call is cancelled.
+	Detect(ctx context.Context, opts ...grpc.CallOption) (Porcupine_DetectClient, error)
+}
+
+type porcupineClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPorcupineClient(cc grpc.ClientConnInterface) PorcupineClient {
+	return &porcupineClient{cc}
+}
+
+func (c *porcupineClient) Detect(ctx context.Context, opts ...grpc.CallOption) (Porcupine_DetectClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Porcupine_ServiceDesc.Streams[0], Porcupine_Detect_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &porcupineDetectClient{stream}
+	return x, nil
+}
+
+type Porcupine_DetectClient interface {
+	Send(*AudioChunk) error
+	Recv() (*DetectionEvent, error)
+	grpc.ClientStream
+}
+
+type porcupineDetectClient struct {
+	grpc.ClientStream
+}
+
+func (x *porcupineDetectClient) Send(m *AudioChunk) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *porcupineDetectClient) Recv() (*DetectionEvent, error) {
+	m := new(DetectionEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// PorcupineServer is the server API for Porcupine service.
+// All implementations must embed UnimplementedPorcupineServer
+// for forward compatibility
+type PorcupineServer interface {
+	// Streams audio to an engine from the server's pool and receives an event for every detected keyword. The
+	// stream holds on to the engine until the client closes its side of the stream or the call is cancelled.
+	Detect(Porcupine_DetectServer) error
+	mustEmbedUnimplementedPorcupineServer()
+}
+
+// UnimplementedPorcupineServer must be embedded to have forward compatible implementations.
+type UnimplementedPorcupineServer struct {
+}
+
+func (UnimplementedPorcupineServer) Detect(Porcupine_DetectServer) error {
+	return status.Errorf(codes.Unimplemented, "method Detect not implemented")
+}
+func (UnimplementedPorcupineServer) mustEmbedUnimplementedPorcupineServer() {}
+
+// UnsafePorcupineServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PorcupineServer will
+// result in compilation errors.
+type UnsafePorcupineServer interface {
+	mustEmbedUnimplementedPorcupineServer()
+}
+
+func RegisterPorcupineServer(s grpc.ServiceRegistrar, srv PorcupineServer) {
+	s.RegisterService(&Porcupine_ServiceDesc, srv)
+}
+
+func _Porcupine_Detect_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(PorcupineServer).Detect(&porcupineDetectServer{stream})
+}
+
+type Porcupine_DetectServer interface {
+	Send(*DetectionEvent) error
+	Recv() (*AudioChunk, error)
+	grpc.ServerStream
+}
+
+type porcupineDetectServer struct {
+	grpc.ServerStream
+}
+
+func (x *porcupineDetectServer) Send(m *DetectionEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *porcupineDetectServer) Recv() (*AudioChunk, error) {
+	m := new(AudioChunk)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Porcupine_ServiceDesc is the grpc.ServiceDesc for Porcupine service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Porcupine_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "porcupine.Porcupine",
+	HandlerType: (*PorcupineServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Detect",
+			Handler:       _Porcupine_Detect_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "porcupine.proto",
+}
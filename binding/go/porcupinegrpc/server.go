@@ -0,0 +1,166 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package porcupinegrpc exposes the Porcupine wake word engine as a gRPC service. Clients stream audio over the
+// `Detect` RPC and receive an event for every keyword detected in it. Each stream is served by an engine taken
+// from a fixed-size pool, so the number of concurrent streams is bounded by the size of the pool.
+//
+// The service is defined in porcupine.proto. Regenerate the Go code after changing it with:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative porcupine.proto
+package porcupinegrpc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+
+	porcupine "github.com/Picovoice/porcupine/binding/go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server struct
+type Server struct {
+	UnimplementedPorcupineServer
+
+	// idle engines; a stream takes one for its whole lifetime and returns it when done
+	engines chan *porcupine.Porcupine
+
+	// creates replacements for engines that can no longer be reset
+	newEngine func() (*porcupine.Porcupine, error)
+
+	mutex sync.Mutex
+	all   []*porcupine.Porcupine
+}
+
+// Creates a Server backed by `poolSize` engines created with `newEngine`. `newEngine` must return an engine that
+// has already been initialized. Engines are reused across streams and are released by `Close`. An engine that
+// fails to reset for a new stream is released and replaced with a new one from `newEngine`; if that fails too, the
+// pool shrinks by one engine.
+func NewServer(poolSize int, newEngine func() (*porcupine.Porcupine, error)) (*Server, error) {
+	if poolSize <= 0 {
+		return nil, fmt.Errorf("Pool size of %d is invalid. Must be greater than 0.", poolSize)
+	}
+
+	server := &Server{engines: make(chan *porcupine.Porcupine, poolSize), newEngine: newEngine}
+	for i := 0; i < poolSize; i++ {
+		engine, err := newEngine()
+		if err != nil {
+			server.Close()
+			return nil, err
+		}
+		server.all = append(server.all, engine)
+		server.engines <- engine
+	}
+	return server, nil
+}
+
+// Releases the engines in the pool. Must only be called once the gRPC server serving it has stopped.
+func (server *Server) Close() error {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	var firstErr error
+	for _, engine := range server.all {
+		if err := engine.Delete(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	server.all = nil
+	return firstErr
+}
+
+// Detect implements PorcupineServer. It waits for an idle engine, then processes the incoming audio until the
+// client closes its side of the stream or the call is cancelled.
+func (server *Server) Detect(stream Porcupine_DetectServer) error {
+	ctx := stream.Context()
+
+	var engine *porcupine.Porcupine
+	select {
+	case engine = <-server.engines:
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	}
+
+	// the previous stream may have left detection state behind
+	if err := engine.Reset(); err != nil {
+		server.replace(engine)
+		return status.Errorf(codes.Internal, "%v", err)
+	}
+	defer func() { server.engines <- engine }()
+
+	frameLength := engine.Config().FrameLength
+	frame := make([]int16, 0, frameLength)
+	var samplesProcessed int64
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			// a trailing partial frame is dropped
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		pcm := chunk.GetPcm()
+		if len(pcm)%2 != 0 {
+			return status.Errorf(codes.InvalidArgument, "Audio chunk has an odd number of bytes (%d)", len(pcm))
+		}
+
+		for i := 0; i < len(pcm); i += 2 {
+			frame = append(frame, int16(binary.LittleEndian.Uint16(pcm[i:i+2])))
+			if len(frame) < frameLength {
+				continue
+			}
+
+			keywordIndex, err := engine.Process(frame)
+			if err != nil {
+				return status.Errorf(codes.Internal, "%v", err)
+			}
+			frame = frame[:0]
+			samplesProcessed += int64(frameLength)
+
+			if keywordIndex >= 0 {
+				err = stream.Send(&DetectionEvent{
+					KeywordIndex: int32(keywordIndex),
+					SampleOffset: samplesProcessed,
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// Releases an engine that can no longer be used and puts a new one in the pool in its place, or shrinks the pool if
+// no new engine can be created.
+func (server *Server) replace(broken *porcupine.Porcupine) {
+	broken.Delete()
+	replacement, err := server.newEngine()
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	for i, engine := range server.all {
+		if engine == broken {
+			server.all = append(server.all[:i], server.all[i+1:]...)
+			break
+		}
+	}
+	if err != nil {
+		return
+	}
+	server.all = append(server.all, replacement)
+	server.engines <- replacement
+}
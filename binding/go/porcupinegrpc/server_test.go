@@ -0,0 +1,185 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porcupinegrpc
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"testing"
+
+	porcupine "github.com/Picovoice/porcupine/binding/go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newTestEngine() (*porcupine.Porcupine, error) {
+	p := &porcupine.Porcupine{BuiltInKeywords: []porcupine.BuiltInKeyword{porcupine.PORCUPINE}}
+	return p, p.Init()
+}
+
+func startServer(t *testing.T, poolSize int) (PorcupineClient, func()) {
+	return startServerWith(t, poolSize, newTestEngine)
+}
+
+func startServerWith(t *testing.T, poolSize int, newEngine func() (*porcupine.Porcupine, error)) (PorcupineClient, func()) {
+	server, err := NewServer(poolSize, newEngine)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer()
+	RegisterPorcupineServer(grpcServer, server)
+	go grpcServer.Serve(listener)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	return NewPorcupineClient(conn), func() {
+		conn.Close()
+		grpcServer.Stop()
+		server.Close()
+	}
+}
+
+func readTestAudio(t *testing.T) []byte {
+	testFile, _ := filepath.Abs("../../../resources/audio_samples/porcupine.wav")
+	data, err := ioutil.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("Could not read test file: %v", err)
+	}
+	return data[44:] // skip header
+}
+
+func detect(t *testing.T, client PorcupineClient, data []byte) []*DetectionEvent {
+	stream, err := client.Detect(context.Background())
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	// chunks deliberately don't align with the frame length
+	for start := 0; start < len(data); start += 1000 {
+		end := start + 1000
+		if end > len(data) {
+			end = len(data)
+		}
+		if err := stream.Send(&AudioChunk{Pcm: data[start:end]}); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+	stream.CloseSend()
+
+	var events []*DetectionEvent
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestDetect(t *testing.T) {
+	client, stop := startServer(t, 1)
+	defer stop()
+
+	events := detect(t, client, readTestAudio(t))
+	if len(events) != 1 || events[0].KeywordIndex != 0 {
+		t.Fatalf("Expected a single detection of keyword 0, but got %v", events)
+	}
+	t.Logf("Keyword detected at sample %d", events[0].SampleOffset)
+}
+
+func TestDetectReusedEngine(t *testing.T) {
+	client, stop := startServer(t, 1)
+	defer stop()
+
+	data := readTestAudio(t)
+	// end the first stream mid-utterance and on a partial frame, so stale state would shift the second stream
+	first := detect(t, client, data[:2*47764])
+	if len(first) != 0 {
+		t.Fatalf("Expected no detection in the truncated stream, but got %v", first)
+	}
+
+	want := detect(t, client, data)
+	for i := 0; i < 2; i++ {
+		got := detect(t, client, data)
+		if len(got) != len(want) || len(got) != 1 || got[0].SampleOffset != want[0].SampleOffset {
+			t.Fatalf("Expected %v from a reused engine, but got %v", want, got)
+		}
+	}
+}
+
+func TestDetectReplacesBrokenEngine(t *testing.T) {
+	var engines []*porcupine.Porcupine
+	client, stop := startServerWith(t, 1, func() (*porcupine.Porcupine, error) {
+		engine, err := newTestEngine()
+		engines = append(engines, engine)
+		return engine, err
+	})
+	defer stop()
+
+	// a deleted engine fails to reset for the next stream
+	engines[0].Delete()
+	stream, err := client.Detect(context.Background())
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	stream.CloseSend()
+	if _, err := stream.Recv(); status.Code(err) != codes.Internal {
+		t.Fatalf("Expected the stream on the broken engine to fail, but got %v", err)
+	}
+
+	events := detect(t, client, readTestAudio(t))
+	if len(events) != 1 || len(engines) != 2 {
+		t.Fatalf("Expected a detection from a replacement engine, but got %v from %d engines", events, len(engines))
+	}
+}
+
+func TestDetectCancelledWhileWaitingForEngine(t *testing.T) {
+	client, stop := startServer(t, 1)
+	defer stop()
+
+	// hold the only engine in the pool
+	busy, err := client.Detect(context.Background())
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer busy.CloseSend()
+	if err := busy.Send(&AudioChunk{Pcm: make([]byte, 2)}); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	waiting, err := client.Detect(ctx)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	cancel()
+
+	_, err = waiting.Recv()
+	if status.Code(err) != codes.Canceled {
+		t.Fatalf("Expected stream to be cancelled, but got %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package porcupine
+
+import "io"
+
+// Recognizer struct
+type Recognizer struct {
+	porcupine  *Porcupine
+	src        io.Reader
+	frameBytes []byte
+	frame      []int16
+}
+
+// Creates a recognizer that detects keywords with `porcupine` in 16-bit little-endian PCM read from `src`, e.g. a
+// network connection or a pipe from a recorder. `porcupine` must be initialized and is not released by the
+// recognizer.
+func NewRecognizer(porcupine *Porcupine, src io.Reader) *Recognizer {
+	return &Recognizer{
+		porcupine:  porcupine,
+		src:        src,
+		frameBytes: make([]byte, porcupine.frameLength*2),
+		frame:      make([]int16, porcupine.frameLength),
+	}
+}
+
+// Reads and processes audio frame by frame until a keyword is detected, and returns the detection. Short reads
+// are buffered until a whole frame has been read. Returns io.EOF once the source is exhausted; a trailing partial
+// frame is ignored.
+func (recognizer *Recognizer) Next() (Detection, error) {
+	for {
+		if _, err := io.ReadFull(recognizer.src, recognizer.frameBytes); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				err = io.EOF
+			}
+			return Detection{}, err
+		}
+
+		keywordIndex, err := recognizer.porcupine.ProcessBytesInto(recognizer.frameBytes, recognizer.frame)
+		if err != nil {
+			return Detection{}, err
+		}
+		if keywordIndex >= 0 {
+			return recognizer.porcupine.lastDetection(keywordIndex), nil
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porcupine
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/iotest"
+)
+
+func TestRecognizer(t *testing.T) {
+	requireNativeLibrary(t)
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/multiple_keywords.wav")
+	f, err := os.Open(test_file)
+	if err != nil {
+		t.Fatalf("Could not read test file: %v", err)
+	}
+	defer f.Close()
+	data, err := readWAVHeader(f)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{ALEXA, PORCUPINE}}
+	err = p.Init()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	// reading a byte at a time makes every frame arrive in many short reads
+	recognizer := NewRecognizer(&p, iotest.OneByteReader(data))
+	var results []int
+	lastFrame := int64(-1)
+	for {
+		detection, err := recognizer.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if detection.FrameIndex <= lastFrame {
+			t.Fatalf("Expected detections in increasing frames, but got frame %d after %d", detection.FrameIndex,
+				lastFrame)
+		}
+		lastFrame = detection.FrameIndex
+		results = append(results, detection.Index)
+	}
+
+	expected := []int{1, 0, 1}
+	if len(results) != len(expected) || results[0] != 1 || results[1] != 0 || results[2] != 1 {
+		t.Fatalf("Expected detections %v, but got %v", expected, results)
+	}
+	if _, err := recognizer.Next(); err != io.EOF {
+		t.Fatalf("Expected io.EOF once the source is exhausted, but got: %v", err)
+	}
+}
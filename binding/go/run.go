@@ -0,0 +1,102 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package porcupine
+
+import (
+	"context"
+	"io"
+)
+
+// FrameSource interface
+type FrameSource interface {
+	// Reads the next frame of audio into `frame`, which holds `FrameLength()` samples. Returns io.EOF once there is
+	// no more audio.
+	ReadFrame(frame []int16) error
+}
+
+// FrameEvent struct
+type FrameEvent struct {
+	// Index of the frame, counted from Init.
+	FrameIndex int64
+
+	// RMS level of the frame, from 0 (silence) to 1 (full scale).
+	Level float64
+
+	// Keyword detected in the frame, or nil if there was none.
+	Detection *Detection
+}
+
+// Processes frames read from `src` until it returns io.EOF, `ctx` is cancelled or processing fails, calling
+// `onDetection` for every keyword detected. Returns nil once the source is exhausted and `ctx.Err()` if `ctx` is
+// cancelled. `onDetection` is called on the goroutine calling Run and should return quickly so that the source
+// does not overrun.
+func (porcupine *Porcupine) Run(ctx context.Context, src FrameSource, onDetection func(Detection)) error {
+	return porcupine.run(ctx, src, false, func(event FrameEvent) {
+		if event.Detection != nil && onDetection != nil {
+			onDetection(*event.Detection)
+		}
+	})
+}
+
+// Same as `Run`, but calls `onFrame` for every frame with its RMS level and detection, if any. Useful for
+// visualizers that draw the input level and wake word events on one timeline. Computing the level costs a pass
+// over every frame, so use `Run` when only detections are needed.
+func (porcupine *Porcupine) RunWithLevels(ctx context.Context, src FrameSource, onFrame func(FrameEvent)) error {
+	return porcupine.run(ctx, src, true, onFrame)
+}
+
+func (porcupine *Porcupine) run(ctx context.Context, src FrameSource, withLevels bool, onFrame func(FrameEvent)) error {
+	porcupine.mutex.Lock()
+	initialized, frameLength := porcupine.handle != nil, porcupine.frameLength
+	porcupine.mutex.Unlock()
+	if !initialized {
+		return newPorcupineError(INVALID_STATE, "Porcupine has not been initialized or has been deleted.")
+	}
+
+	frame := make([]int16, frameLength)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := src.ReadFrame(frame); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		// the frame index and detection are read together with processing, so that a concurrent Reset can't
+		// shift them
+		porcupine.mutex.Lock()
+		keywordIndex, err := porcupine.process(frame)
+		event := FrameEvent{FrameIndex: porcupine.frameCount - 1}
+		if err == nil && keywordIndex >= 0 {
+			detection := porcupine.newDetection(keywordIndex, event.FrameIndex)
+			event.Detection = &detection
+		}
+		porcupine.mutex.Unlock()
+		if err != nil {
+			return err
+		}
+		if keywordIndex < 0 && !withLevels {
+			continue
+		}
+
+		if withLevels {
+			event.Level = rmsLevel(frame)
+		}
+		if onFrame != nil {
+			onFrame(event)
+		}
+	}
+}
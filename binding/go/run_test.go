@@ -0,0 +1,165 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porcupine
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+// serves frames from a buffer of samples
+type sliceFrameSource struct {
+	pcm []int16
+}
+
+func (src *sliceFrameSource) ReadFrame(frame []int16) error {
+	if len(src.pcm) < len(frame) {
+		return io.EOF
+	}
+	copy(frame, src.pcm)
+	src.pcm = src.pcm[len(frame):]
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	requireNativeLibrary(t)
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/multiple_keywords.wav")
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{ALEXA, PORCUPINE}}
+	err := p.Init()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	var keywords []string
+	err = p.Run(context.Background(), &sliceFrameSource{pcm: readTestAudio(t, test_file)}, func(detection Detection) {
+		keywords = append(keywords, detection.Keyword)
+	})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	expected := []string{string(PORCUPINE), string(ALEXA), string(PORCUPINE)}
+	if len(keywords) != len(expected) {
+		t.Fatalf("Expected detections %v, but got %v", expected, keywords)
+	}
+	for i := range expected {
+		if keywords[i] != expected[i] {
+			t.Fatalf("Expected detections %v, but got %v", expected, keywords)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := p.Run(ctx, &sliceFrameSource{pcm: make([]int16, FrameLength())}, nil); err != context.Canceled {
+		t.Fatalf("Expected context.Canceled, but got %v", err)
+	}
+}
+
+func TestRunWithLevels(t *testing.T) {
+	requireNativeLibrary(t)
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/multiple_keywords.wav")
+	pcm := readTestAudio(t, test_file)
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{ALEXA, PORCUPINE}}
+	err := p.Init()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	var events []FrameEvent
+	err = p.RunWithLevels(context.Background(), &sliceFrameSource{pcm: pcm}, func(event FrameEvent) {
+		events = append(events, event)
+	})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if len(events) != len(pcm)/FrameLength() {
+		t.Fatalf("Expected an event for each of the %d frames, but got %d", len(pcm)/FrameLength(), len(events))
+	}
+
+	var keywords []string
+	loudFrames := 0
+	for i, event := range events {
+		if event.FrameIndex != int64(i) {
+			t.Fatalf("Expected frame index %d, but got %d", i, event.FrameIndex)
+		}
+		if event.Level < 0 || event.Level > 1 {
+			t.Fatalf("Level %f of frame %d is out of range", event.Level, i)
+		}
+		if event.Level > 0.01 {
+			loudFrames++
+		}
+		if event.Detection != nil {
+			if event.Detection.FrameIndex != event.FrameIndex {
+				t.Fatalf("Detection in frame %d reports frame %d", event.FrameIndex, event.Detection.FrameIndex)
+			}
+			keywords = append(keywords, event.Detection.Keyword)
+		}
+	}
+
+	if loudFrames == 0 {
+		t.Fatalf("Expected some frames with speech to have a non-zero level.")
+	}
+	if len(keywords) != 3 || keywords[0] != string(PORCUPINE) || keywords[1] != string(ALEXA) || keywords[2] != string(PORCUPINE) {
+		t.Fatalf("Expected detections [porcupine alexa porcupine], but got %v", keywords)
+	}
+}
+
+func TestRunConcurrentWithReset(t *testing.T) {
+	native := &testNative{version: "1.9.0", processResults: []int{-1, 0, -1, 0, -1, 0}}
+	p := Porcupine{LibraryPath: registerTestNative(t, native), BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	// run with -race to check that the frame index isn't read outside the mutex
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			if err := p.Reset(); err != nil {
+				t.Errorf("%v", err)
+				return
+			}
+		}
+	}()
+
+	var events []FrameEvent
+	err := p.RunWithLevels(context.Background(), &sliceFrameSource{pcm: make([]int16, 100*FrameLength())},
+		func(event FrameEvent) {
+			events = append(events, event)
+		})
+	<-done
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if len(events) != 100 {
+		t.Fatalf("Expected an event per frame, but got %d events", len(events))
+	}
+	for _, event := range events {
+		if event.FrameIndex < 0 {
+			t.Fatalf("Expected non-negative frame indices, but got %+v", event)
+		}
+		if event.Detection != nil && event.Detection.FrameIndex != event.FrameIndex {
+			t.Fatalf("Expected the detection in frame %d, but got %+v", event.FrameIndex, event.Detection)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package porcupine
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Checks that the native library, model and keyword files work end-to-end by detecting the built-in `PORCUPINE`
+// keyword in an embedded recording of it. Returns nil if exactly that detection is made and a descriptive error
+// otherwise. Useful as a readiness probe at startup; it takes about as long as creating an instance and
+// processing a few seconds of audio.
+func SelfTest() error {
+	data, err := embeddedFS.ReadFile("embedded/resources/audio_samples/porcupine.wav")
+	if err != nil {
+		return newPorcupineError(IO_ERROR, "Self-test audio is not embedded in this build: %v", err)
+	}
+	pcm, err := DecodeWAV(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("self-test: %w", err)
+	}
+
+	porcupine := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	if err := porcupine.Init(); err != nil {
+		return fmt.Errorf("self-test: %w", err)
+	}
+	defer porcupine.Delete()
+
+	var detections []int
+	for i := 0; i+porcupine.frameLength <= len(pcm); i += porcupine.frameLength {
+		keywordIndex, err := porcupine.Process(pcm[i : i+porcupine.frameLength])
+		if err != nil {
+			return fmt.Errorf("self-test: %w", err)
+		}
+		if keywordIndex >= 0 {
+			detections = append(detections, keywordIndex)
+		}
+	}
+
+	if len(detections) != 1 || detections[0] != 0 {
+		return newPorcupineError(RUNTIME_ERROR, "Self-test expected a single detection of '%s', but got %d "+
+			"detections. The native library or model may be corrupt.", PORCUPINE, len(detections))
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package porcupine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// EngineConfig struct
+type EngineConfig struct {
+	ModelPath                string                     `json:"model_path,omitempty"`
+	Language                 Language                   `json:"language,omitempty"`
+	BuiltInKeywords          []BuiltInKeyword           `json:"builtin_keywords,omitempty"`
+	KeywordPaths             []string                   `json:"keyword_paths,omitempty"`
+	Sensitivities            []float32                  `json:"sensitivities,omitempty"`
+	BuiltInSensitivities     map[BuiltInKeyword]float32 `json:"builtin_sensitivities,omitempty"`
+	KeywordPathSensitivities map[string]float32         `json:"keyword_path_sensitivities,omitempty"`
+	StrictSensitivities      bool                       `json:"strict_sensitivities,omitempty"`
+	ModelData                []byte                     `json:"model_data,omitempty"`
+	KeywordData              [][]byte                   `json:"keyword_data,omitempty"`
+	KeywordSet               *KeywordSet                `json:"keyword_set,omitempty"`
+	LibraryPath              string                     `json:"library_path,omitempty"`
+	NonFinitePolicy          NonFinitePolicy            `json:"non_finite_policy,omitempty"`
+	DetectionHistorySize     int                        `json:"detection_history_size,omitempty"`
+	MinDetectionGap          time.Duration              `json:"min_detection_gap,omitempty"`
+	InitTimeout              time.Duration              `json:"init_timeout,omitempty"`
+}
+
+// Configures the instance from an `EngineConfig`, e.g. one read from a JSON configuration file. Built-in keywords
+// are validated when the configuration is decoded. The AccessKey is not part of the configuration, so that it can
+// be kept out of configuration files; set it with `WithAccessKey`.
+func WithEngineConfig(config EngineConfig) Option {
+	return func(porcupine *Porcupine) {
+		porcupine.ModelPath = config.ModelPath
+		porcupine.Language = config.Language
+		porcupine.BuiltInKeywords = config.BuiltInKeywords
+		porcupine.KeywordPaths = config.KeywordPaths
+		porcupine.Sensitivities = config.Sensitivities
+		porcupine.BuiltInSensitivities = config.BuiltInSensitivities
+		porcupine.KeywordPathSensitivities = config.KeywordPathSensitivities
+		porcupine.StrictSensitivities = config.StrictSensitivities
+		porcupine.ModelData = config.ModelData
+		porcupine.KeywordData = config.KeywordData
+		porcupine.KeywordSet = config.KeywordSet
+		porcupine.LibraryPath = config.LibraryPath
+		porcupine.NonFinitePolicy = config.NonFinitePolicy
+		porcupine.DetectionHistorySize = config.DetectionHistorySize
+		porcupine.MinDetectionGap = config.MinDetectionGap
+		porcupine.InitTimeout = config.InitTimeout
+	}
+}
+
+// Writes the configuration of the instance to `w` so an identical instance can be created with `LoadState`.
+// Only the configuration is serialized, not the internal state of the native engine. An empty `ModelPath` is
+// saved as-is so the restored instance uses the model bundled with the package it is loaded by. `ModelData` and
+// `KeywordData` are saved with their contents. The AccessKey, `Observer` and a model set with `SetModelFS` are not
+// saved.
+func (porcupine *Porcupine) SaveState(w io.Writer) error {
+	state := EngineConfig{
+		ModelPath:                porcupine.ModelPath,
+		Language:                 porcupine.Language,
+		BuiltInKeywords:          porcupine.BuiltInKeywords,
+		KeywordPaths:             porcupine.KeywordPaths,
+		Sensitivities:            porcupine.Sensitivities,
+		BuiltInSensitivities:     porcupine.BuiltInSensitivities,
+		KeywordPathSensitivities: porcupine.KeywordPathSensitivities,
+		StrictSensitivities:      porcupine.StrictSensitivities,
+		ModelData:                porcupine.ModelData,
+		KeywordData:              porcupine.KeywordData,
+		KeywordSet:               porcupine.KeywordSet,
+		LibraryPath:              porcupine.LibraryPath,
+		NonFinitePolicy:          porcupine.NonFinitePolicy,
+		DetectionHistorySize:     porcupine.DetectionHistorySize,
+		MinDetectionGap:          porcupine.MinDetectionGap,
+		InitTimeout:              porcupine.InitTimeout,
+	}
+
+	if err := json.NewEncoder(w).Encode(&state); err != nil {
+		return fmt.Errorf("Failed to save Porcupine state: %v", err)
+	}
+	return nil
+}
+
+// Reads a configuration written by `SaveState` and returns an initialized instance created from it. The options
+// are applied after the saved configuration, e.g. `WithAccessKey` to supply the AccessKey, which isn't saved, or
+// `WithObserver`.
+func LoadState(r io.Reader, opts ...Option) (*Porcupine, error) {
+	var state EngineConfig
+	if err := json.NewDecoder(r).Decode(&state); err != nil {
+		return nil, fmt.Errorf("Failed to load Porcupine state: %v", err)
+	}
+
+	return NewPorcupine(append([]Option{WithEngineConfig(state)}, opts...)...)
+}
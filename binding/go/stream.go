@@ -0,0 +1,133 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package porcupine
+
+import (
+	"context"
+)
+
+// Number of detections buffered per keyword channel by DetectionChannels.
+const DetectionChannelBuffer = 16
+
+// Processes frames from `src` in a background goroutine and routes each detection to a channel for its keyword,
+// keyed by keyword label. Keywords sharing a label share a channel. Each channel buffers up to
+// `DetectionChannelBuffer` detections; when a channel is full further detections for it are dropped and counted
+// in `Stats().DroppedDetections`, so a slow consumer of one keyword never blocks the others.
+//
+// Processing stops when `src` is closed, `ctx` is cancelled or processing fails. At most one error is sent on the
+// returned error channel (`ctx.Err()` on cancellation) and all channels are closed when processing stops. The
+// instance must not be used by other goroutines until then.
+func (porcupine *Porcupine) DetectionChannels(ctx context.Context, src <-chan []int16) (map[string]<-chan Detection, <-chan error) {
+	channels := make(map[string]chan Detection)
+	outputs := make(map[string]<-chan Detection)
+	for _, label := range porcupine.keywordLabels {
+		if _, ok := channels[label]; !ok {
+			channels[label] = make(chan Detection, DetectionChannelBuffer)
+			outputs[label] = channels[label]
+		}
+	}
+	errs := make(chan error, 1)
+
+	go func() {
+		defer func() {
+			for _, ch := range channels {
+				close(ch)
+			}
+			close(errs)
+		}()
+
+		for {
+			var frame []int16
+			var ok bool
+			select {
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			case frame, ok = <-src:
+				if !ok {
+					return
+				}
+			}
+
+			keywordIndex, err := porcupine.Process(frame)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if keywordIndex < 0 {
+				continue
+			}
+
+			detection := porcupine.lastDetection(keywordIndex)
+			select {
+			case channels[detection.Keyword] <- detection:
+			default:
+				porcupine.mutex.Lock()
+				porcupine.stats.DroppedDetections++
+				porcupine.mutex.Unlock()
+			}
+		}
+	}()
+
+	return outputs, errs
+}
+
+// Processes frames from `frames` in a background goroutine and sends every detection on the returned detection
+// channel, which buffers up to `DetectionChannelBuffer` detections. Unlike `DetectionChannels` no detection is
+// dropped: processing waits for the consumer once the buffer is full.
+//
+// Processing stops when `frames` is closed, `ctx` is cancelled or processing fails. At most one error is sent on
+// the returned error channel (`ctx.Err()` on cancellation) and both channels are closed when processing stops.
+// The instance must not be used by other goroutines until then.
+func (porcupine *Porcupine) ProcessStream(ctx context.Context, frames <-chan []int16) (<-chan Detection, <-chan error) {
+	detections := make(chan Detection, DetectionChannelBuffer)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer func() {
+			close(detections)
+			close(errs)
+		}()
+
+		for {
+			var frame []int16
+			var ok bool
+			select {
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			case frame, ok = <-frames:
+				if !ok {
+					return
+				}
+			}
+
+			keywordIndex, err := porcupine.Process(frame)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if keywordIndex < 0 {
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			case detections <- porcupine.lastDetection(keywordIndex):
+			}
+		}
+	}()
+
+	return detections, errs
+}
@@ -0,0 +1,161 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porcupine
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// sends the audio in a file frame by frame and closes the channel at the end
+func sendTestFrames(t *testing.T, path string) <-chan []int16 {
+	pcm := readTestAudio(t, path)
+	frames := make(chan []int16)
+	go func() {
+		defer close(frames)
+		for i := 0; i < len(pcm)/FrameLength(); i++ {
+			frames <- pcm[i*FrameLength() : (i+1)*FrameLength()]
+		}
+	}()
+	return frames
+}
+
+func TestDetectionChannels(t *testing.T) {
+	requireNativeLibrary(t)
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/multiple_keywords.wav")
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{ALEXA, PORCUPINE}}
+	err := p.Init()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	channels, errs := p.DetectionChannels(context.Background(), sendTestFrames(t, test_file))
+	if len(channels) != 2 {
+		t.Fatalf("Expected a channel per keyword, but got %d channels", len(channels))
+	}
+
+	var wg sync.WaitGroup
+	counts := make(map[string]int)
+	var countsMutex sync.Mutex
+	for label, ch := range channels {
+		wg.Add(1)
+		go func(label string, ch <-chan Detection) {
+			defer wg.Done()
+			for detection := range ch {
+				if detection.Keyword != label {
+					t.Errorf("Detection of '%s' routed to channel for '%s'", detection.Keyword, label)
+				}
+				countsMutex.Lock()
+				counts[label]++
+				countsMutex.Unlock()
+			}
+		}(label, ch)
+	}
+
+	for err := range errs {
+		t.Fatalf("%v", err)
+	}
+	wg.Wait()
+
+	if counts[string(ALEXA)] != 1 || counts[string(PORCUPINE)] != 2 {
+		t.Fatalf("Expected 1 detection of '%s' and 2 of '%s', but got %v", ALEXA, PORCUPINE, counts)
+	}
+}
+
+func TestDetectionChannelsDropped(t *testing.T) {
+	results := make([]int, DetectionChannelBuffer+3)
+	native := &testNative{version: "1.9.0", processResults: results}
+	p := Porcupine{LibraryPath: registerTestNative(t, native), BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	if err := p.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	frames := make(chan []int16)
+	channels, errs := p.DetectionChannels(context.Background(), frames)
+	for range results {
+		frames <- make([]int16, FrameLength())
+		// run with -race to check that reading the counters doesn't race with the processing goroutine
+		p.Stats()
+	}
+	close(frames)
+	for err := range errs {
+		t.Fatalf("%v", err)
+	}
+
+	if received := len(channels[string(PORCUPINE)]); received != DetectionChannelBuffer {
+		t.Fatalf("Expected %d buffered detections, but got %d", DetectionChannelBuffer, received)
+	}
+	if dropped := p.Stats().DroppedDetections; dropped != 3 {
+		t.Fatalf("Expected 3 dropped detections, but got %d", dropped)
+	}
+}
+
+func TestProcessStream(t *testing.T) {
+	requireNativeLibrary(t)
+
+	test_file, _ := filepath.Abs("../../resources/audio_samples/multiple_keywords.wav")
+
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{ALEXA, PORCUPINE}}
+	err := p.Init()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	detections, errs := p.ProcessStream(context.Background(), sendTestFrames(t, test_file))
+	var labels []string
+	var lastTimestamp time.Duration
+	for detection := range detections {
+		if detection.Timestamp <= lastTimestamp {
+			t.Fatalf("Expected increasing timestamps, but got %v after %v", detection.Timestamp, lastTimestamp)
+		}
+		lastTimestamp = detection.Timestamp
+		labels = append(labels, detection.Keyword)
+	}
+	for err := range errs {
+		t.Fatalf("%v", err)
+	}
+
+	expected := []string{string(PORCUPINE), string(ALEXA), string(PORCUPINE)}
+	if !reflect.DeepEqual(labels, expected) {
+		t.Fatalf("Expected detections %v, but got %v", expected, labels)
+	}
+}
+
+func TestProcessStreamCancel(t *testing.T) {
+	p := Porcupine{BuiltInKeywords: []BuiltInKeyword{PORCUPINE}}
+	err := p.Init()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer p.Delete()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	frames := make(chan []int16)
+	detections, errs := p.ProcessStream(ctx, frames)
+	frames <- make([]int16, FrameLength())
+	cancel()
+
+	if err := <-errs; err != context.Canceled {
+		t.Fatalf("Expected %v, but got %v", context.Canceled, err)
+	}
+	if _, ok := <-detections; ok {
+		t.Fatalf("Expected detection channel to be closed after cancellation.")
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package porcupine
+
+import (
+	"time"
+)
+
+// Returns the sample range [start, end) of the audio that follows a wake word, e.g. for forwarding the
+// command that follows to a speech-to-text engine. `detectionOffset` is the index of the first sample after
+// the frame in which the keyword was detected, `postRoll` is the duration of audio to extract and `bufferLen`
+// is the number of samples available in the buffer. The range is clamped to the buffer. The range is empty if the
+// default native library could not be loaded, since its sample rate is unknown.
+func PostRollRange(detectionOffset int, postRoll time.Duration, bufferLen int) (start int, end int) {
+	if detectionOffset < 0 {
+		detectionOffset = 0
+	}
+	if detectionOffset > bufferLen {
+		detectionOffset = bufferLen
+	}
+	if postRoll < 0 {
+		postRoll = 0
+	}
+
+	// whole seconds and the remainder are converted separately, since the product of a long post-roll and the
+	// sample rate overflows
+	sampleRate := time.Duration(SampleRate())
+	postRollSamples := postRoll/time.Second*sampleRate + postRoll%time.Second*sampleRate/time.Second
+	start = detectionOffset
+	if postRollSamples > time.Duration(bufferLen-start) {
+		return start, bufferLen
+	}
+	return start, start + int(postRollSamples)
+}
+
+// Returns the sample range [start, end) of the frame at `frameIndex`, e.g. the `FrameIndex` of a `Detection`, in
+// a stream processed frame by frame from its start. `end` equals the `SampleOffset` of a detection in the frame.
+// Uses `FrameLength()` of the default native library and returns an empty range if it could not be loaded.
+func FrameToSampleRange(frameIndex int) (start int, end int) {
+	frameLength := FrameLength()
+	return frameIndex * frameLength, (frameIndex + 1) * frameLength
+}
+
+// Returns the time from the start of a stream to the sample at index `sample`, at `SampleRate()` of the default
+// native library, so that `SampleToTime` of the end of a detection's frame equals its `Timestamp`. Returns 0 if the
+// library could not be loaded.
+func SampleToTime(sample int) time.Duration {
+	sampleRate := SampleRate()
+	if sampleRate == 0 {
+		return 0
+	}
+	return samplesDuration(int64(sample), sampleRate)
+}
+
+// Returns the duration of `samples` samples at `sampleRate`. Whole seconds and the remainder are converted
+// separately, since the product of the sample count and `time.Second` overflows after about a week of audio.
+func samplesDuration(samples int64, sampleRate int) time.Duration {
+	rate := int64(sampleRate)
+	return time.Duration(samples/rate)*time.Second + time.Duration(samples%rate)*time.Second/time.Duration(rate)
+}
+
+// Processes `frames` frames of silence with `porcupine` and returns the total time taken, for comparing the
+// processing cost on different platforms independent of the audio and of detections. The time per frame, i.e. the
+// result divided by `frames`, must stay well below the duration of a frame, `FrameLength()` / `SampleRate()`, for
+// real-time use. The frames count towards the stream of the instance, so it should be a dedicated instance or be
+// `Reset` afterwards.
+func BenchmarkFrame(porcupine *Porcupine, frames int) (time.Duration, error) {
+	if porcupine == nil {
+		return 0, newPorcupineError(INVALID_ARGUMENT, "Porcupine instance is nil.")
+	}
+	if frames < 0 {
+		return 0, newPorcupineError(INVALID_ARGUMENT, "Number of frames must not be negative, but got %d.", frames)
+	}
+
+	porcupine.mutex.Lock()
+	initialized, frameLength := porcupine.handle != nil, porcupine.frameLength
+	porcupine.mutex.Unlock()
+	if !initialized {
+		return 0, newPorcupineError(INVALID_STATE, "Porcupine has not been initialized or has been deleted.")
+	}
+
+	frame := make([]int16, frameLength)
+	start := time.Now()
+	for i := 0; i < frames; i++ {
+		if _, err := porcupine.Process(frame); err != nil {
+			return time.Since(start), err
+		}
+	}
+	return time.Since(start), nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package porcupine
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+const wavFormatPCM = 1
+
+// Reads the samples of a WAV file. See `DecodeWAV`.
+func ReadWAVFile(path string) ([]int16, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return DecodeWAV(bufio.NewReader(f))
+}
+
+// Decodes WAV audio from `r` and returns its samples. The RIFF header is parsed rather than assumed to be 44 bytes
+// long. The audio must be single-channel, 16-bit linearly-encoded PCM at `SampleRate()`, as required by `Process`;
+// a descriptive error is returned otherwise.
+func DecodeWAV(r io.Reader) ([]int16, error) {
+	data, err := readWAVHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	pcmBytes, err := ioutil.ReadAll(data)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read WAV data: %v", err)
+	}
+
+	pcm := make([]int16, len(pcmBytes)/2)
+	decodePCM(pcm, pcmBytes, binary.LittleEndian)
+	return pcm, nil
+}
+
+// Reads a RIFF/WAVE header from `r` and returns a reader positioned at the start of the sample data, limited
+// to the size of the data chunk. Chunks other than "fmt " and "data" are skipped. The audio must be 16-bit
+// linearly-encoded single-channel PCM at `SampleRate()`.
+func readWAVHeader(r io.Reader) (io.Reader, error) {
+	var riffHeader [12]byte
+	if _, err := io.ReadFull(r, riffHeader[:]); err != nil {
+		return nil, fmt.Errorf("Failed to read WAV header: %v", err)
+	}
+	if string(riffHeader[0:4]) != "RIFF" || string(riffHeader[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("Input is not a RIFF/WAVE file")
+	}
+
+	foundFormat := false
+	for {
+		var chunkHeader [8]byte
+		if _, err := io.ReadFull(r, chunkHeader[:]); err != nil {
+			return nil, fmt.Errorf("Failed to find WAV data chunk: %v", err)
+		}
+		chunkID := string(chunkHeader[0:4])
+		chunkSize := int64(binary.LittleEndian.Uint32(chunkHeader[4:8]))
+
+		switch chunkID {
+		case "fmt ":
+			if chunkSize < 16 {
+				return nil, fmt.Errorf("WAV format chunk is too short (%d bytes)", chunkSize)
+			}
+			var format [16]byte
+			if _, err := io.ReadFull(r, format[:]); err != nil {
+				return nil, fmt.Errorf("Failed to read WAV format chunk: %v", err)
+			}
+			if err := validateWAVFormat(format); err != nil {
+				return nil, err
+			}
+			if err := skipWAVChunk(r, chunkSize-16); err != nil {
+				return nil, err
+			}
+			foundFormat = true
+		case "data":
+			if !foundFormat {
+				return nil, fmt.Errorf("WAV data chunk precedes format chunk")
+			}
+			return io.LimitReader(r, chunkSize), nil
+		default:
+			if err := skipWAVChunk(r, chunkSize); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func validateWAVFormat(format [16]byte) error {
+	audioFormat := binary.LittleEndian.Uint16(format[0:2])
+	numChannels := int(binary.LittleEndian.Uint16(format[2:4]))
+	sampleRate := int(binary.LittleEndian.Uint32(format[4:8]))
+	bitsPerSample := int(binary.LittleEndian.Uint16(format[14:16]))
+
+	if audioFormat != wavFormatPCM || bitsPerSample != 16 || numChannels != 1 || sampleRate != SampleRate() {
+		return newPorcupineError(INVALID_ARGUMENT, "WAV file must contain single-channel, 16-bit, %dHz linearly-encoded PCM "+
+			"(got format %d, %d channels, %d-bit, %dHz)", SampleRate(), audioFormat, numChannels, bitsPerSample, sampleRate)
+	}
+	return nil
+}
+
+// Chunks are padded to an even number of bytes.
+func skipWAVChunk(r io.Reader, size int64) error {
+	if size%2 == 1 {
+		size++
+	}
+	if _, err := io.CopyN(ioutil.Discard, r, size); err != nil {
+		return fmt.Errorf("Failed to read WAV chunk: %v", err)
+	}
+	return nil
+}
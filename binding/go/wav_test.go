@@ -0,0 +1,92 @@
+// Copyright 2021 Picovoice Inc.
+//
+// You may not use this file except in compliance with the license. A copy of the license is
+// located in the "LICENSE" file accompanying this source.
+//
+// Unless required by applicable law or agreed to in writing, software distributed under the
+// License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porcupine
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// builds a WAV file with a LIST chunk before the data, so the header is longer than 44 bytes
+func encodeTestWAV(pcm []int16, sampleRate int, numChannels int, bitsPerSample int) []byte {
+	var buf bytes.Buffer
+	data := make([]byte, len(pcm)*2)
+	for i, sample := range pcm {
+		binary.LittleEndian.PutUint16(data[i*2:], uint16(sample))
+	}
+	list := []byte("INFOISFT\x05\x00\x00\x00test\x00\x00")
+
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(4+8+16+8+len(list)+8+len(data)))
+	buf.WriteString("WAVE")
+
+	buf.WriteString("fmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(16))
+	binary.Write(&buf, binary.LittleEndian, uint16(wavFormatPCM))
+	binary.Write(&buf, binary.LittleEndian, uint16(numChannels))
+	binary.Write(&buf, binary.LittleEndian, uint32(sampleRate))
+	binary.Write(&buf, binary.LittleEndian, uint32(sampleRate*numChannels*bitsPerSample/8))
+	binary.Write(&buf, binary.LittleEndian, uint16(numChannels*bitsPerSample/8))
+	binary.Write(&buf, binary.LittleEndian, uint16(bitsPerSample))
+
+	buf.WriteString("LIST")
+	binary.Write(&buf, binary.LittleEndian, uint32(len(list)))
+	buf.Write(list)
+
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+func TestDecodeWAV(t *testing.T) {
+	pcm := []int16{0, 1, -1, 32767, -32768}
+
+	decoded, err := DecodeWAV(bytes.NewReader(encodeTestWAV(pcm, SampleRate(), 1, 16)))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if !reflect.DeepEqual(decoded, pcm) {
+		t.Fatalf("Expected samples %v, but got %v", pcm, decoded)
+	}
+
+	invalid := map[string][]byte{
+		"stereo":    encodeTestWAV(pcm, SampleRate(), 2, 16),
+		"44.1kHz":   encodeTestWAV(pcm, 44100, 1, 16),
+		"8-bit":     encodeTestWAV(pcm, SampleRate(), 1, 8),
+		"not a WAV": []byte("this is not a WAV file"),
+		"no data":   encodeTestWAV(pcm, SampleRate(), 1, 16)[:60],
+	}
+	for name, data := range invalid {
+		if _, err := DecodeWAV(bytes.NewReader(data)); err == nil {
+			t.Fatalf("Expected an error decoding %s audio.", name)
+		} else if name == "stereo" && !strings.Contains(err.Error(), "single-channel") {
+			t.Fatalf("Expected error to describe the required format, but got: %v", err)
+		}
+	}
+}
+
+func TestReadWAVFile(t *testing.T) {
+	pcm, err := ReadWAVFile("../../resources/audio_samples/porcupine.wav")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(pcm) == 0 {
+		t.Fatalf("Expected samples from WAV file.")
+	}
+
+	if _, err := ReadWAVFile("/does/not/exist.wav"); err == nil {
+		t.Fatalf("Expected an error for a missing file.")
+	}
+}